@@ -0,0 +1,159 @@
+// Package ast provides a generic syntax tree for parsers built on golex.
+//
+// Nodes record their kind, the span of the source they cover and their children.
+// Leaf nodes hold the token they were created from. Parent links are maintained
+// when children are added, and the root can hold the source so every node can
+// return the exact text it covers.
+package ast
+
+import (
+	"github.com/cornejong/golex"
+)
+
+// Kind is the kind of a node, like "Call" or "Identifier"
+type Kind string
+
+const (
+	// KindError is the kind of nodes holding the tokens skipped while recovering from a syntax error
+	KindError Kind = "Error"
+	// KindMissing is the kind of empty nodes standing in for a token that was expected but not found
+	KindMissing Kind = "Missing"
+)
+
+// Node is a node of a syntax tree
+type Node struct {
+	Kind Kind
+	// Span is the part of the source covered by the node
+	Span golex.Span
+	// Token is the token of leaf nodes
+	Token golex.Token
+	// Value optionally holds a parsed value or user data
+	Value    any
+	Children []*Node
+	Parent   *Node
+
+	source []rune
+}
+
+// NewLeaf creates a node covering a single token
+func NewLeaf(kind Kind, token golex.Token) *Node {
+	return &Node{Kind: kind, Span: token.Span(), Token: token, Value: token.Value}
+}
+
+// NewNode creates a node with the children, its span covers all the children
+func NewNode(kind Kind, children ...*Node) *Node {
+	node := &Node{Kind: kind}
+	node.Append(children...)
+
+	return node
+}
+
+// NewError creates an error node with a leaf for every skipped token
+func NewError(tokens ...golex.Token) *Node {
+	node := &Node{Kind: KindError}
+	for _, token := range tokens {
+		node.Append(NewLeaf(Kind(token.Type.String()), token))
+	}
+
+	return node
+}
+
+// NewMissing creates an empty node at the position for a token matching the expected pattern
+func NewMissing(expected golex.Token, position golex.Position) *Node {
+	expected.Position = position
+	expected.End = position
+
+	return &Node{Kind: KindMissing, Span: golex.NewSpan(position, position), Token: expected}
+}
+
+// IsError checks if the node holds tokens skipped by error recovery
+func (n *Node) IsError() bool { return n.Kind == KindError }
+
+// IsMissing checks if the node stands in for a missing token
+func (n *Node) IsMissing() bool { return n.Kind == KindMissing }
+
+// HasErrors checks if the tree contains error or missing nodes
+func (n *Node) HasErrors() bool {
+	found := false
+	Inspect(n, func(node *Node) bool {
+		if node != nil && (node.IsError() || node.IsMissing()) {
+			found = true
+		}
+
+		return !found
+	})
+
+	return found
+}
+
+// Append adds the children to the node, setting their parent and extending the span of the node.
+// Nil children are skipped, empty children without a span do not change the span.
+func (n *Node) Append(children ...*Node) *Node {
+	for _, child := range children {
+		if child == nil {
+			continue
+		}
+
+		n.extend(child)
+		child.Parent = n
+		n.Children = append(n.Children, child)
+	}
+
+	return n
+}
+
+// extend extends the span of the node to the span of the child, children without a span are skipped
+func (n *Node) extend(child *Node) {
+	switch {
+	case child.Span == (golex.Span{}):
+	case n.Span == (golex.Span{}):
+		n.Span = child.Span
+	default:
+		n.Span = n.Span.Union(child.Span)
+	}
+}
+
+// IsLeaf checks if the node has no children
+func (n *Node) IsLeaf() bool { return len(n.Children) == 0 }
+
+// Root returns the root of the tree containing the node
+func (n *Node) Root() *Node {
+	for n.Parent != nil {
+		n = n.Parent
+	}
+
+	return n
+}
+
+// Index returns the position of the node among the children of its parent, or -1 for the root
+func (n *Node) Index() int {
+	if n.Parent == nil {
+		return -1
+	}
+
+	for i, child := range n.Parent.Children {
+		if child == n {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// SetSource sets the source the node and its descendants were parsed from
+func (n *Node) SetSource(source string) *Node {
+	n.source = []rune(source)
+	return n
+}
+
+// Source returns the exact part of the source the node covers.
+// The source is looked up on the node and its ancestors, without one it returns the token literal.
+func (n *Node) Source() string {
+	for node := n; node != nil; node = node.Parent {
+		if node.source != nil {
+			return n.Span.Text(node.source)
+		}
+	}
+
+	return n.Token.Literal
+}
@@ -0,0 +1,236 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cornejong/golex"
+	"github.com/cornejong/golex/internal/golextest"
+)
+
+// getTree builds the tree of "x = 1 + f(2, y)"
+func getTree(t *testing.T) (*Node, string) {
+	t.Helper()
+
+	source := "x = 1 + f(2, y)"
+	tokens := golextest.Tokenize(t, source)
+
+	call := NewNode("Call",
+		NewLeaf("Identifier", tokens[4]),
+		NewLeaf("Number", tokens[6]),
+		NewLeaf("Identifier", tokens[8]),
+	)
+	call.Append(NewLeaf("CloseParen", tokens[9]))
+
+	root := NewNode("Assign",
+		NewLeaf("Identifier", tokens[0]),
+		NewNode("Add", NewLeaf("Number", tokens[2]), call),
+	)
+
+	return root.SetSource(source), source
+}
+
+type kindCollector struct {
+	kinds *[]string
+	depth int
+}
+
+func (c kindCollector) Visit(node *Node) Visitor {
+	if node == nil {
+		*c.kinds = append(*c.kinds, "end")
+		return nil
+	}
+
+	*c.kinds = append(*c.kinds, fmt.Sprintf("%s%s", strings.Repeat(".", c.depth), node.Kind))
+	return kindCollector{kinds: c.kinds, depth: c.depth + 1}
+}
+
+func TestNodes(t *testing.T) {
+	fmt.Println("TestNodes...")
+
+	root, _ := getTree(t)
+	add := root.Children[1]
+	call := add.Children[1]
+
+	if root.Source() != "x = 1 + f(2, y)" || add.Source() != "1 + f(2, y)" || call.Source() != "f(2, y)" {
+		t.Errorf("Unexpected sources %q, %q and %q", root.Source(), add.Source(), call.Source())
+	}
+
+	if call.Parent != add || call.Root() != root || call.Index() != 1 || root.Index() != -1 {
+		t.Errorf("Unexpected parent links")
+	}
+
+	if span := call.Span; span.Start.Col != 9 || span.End.Col != 16 {
+		t.Errorf("Expected the call to span 9-16 but got %s", span)
+	}
+
+	if leaf := call.Children[1]; !leaf.IsLeaf() || leaf.Value != 2 || leaf.Source() != "2" {
+		t.Errorf("Expected a leaf with the value 2 but got %v", leaf.Value)
+	}
+
+	if detached := NewLeaf("Identifier", root.Children[0].Token); detached.Source() != "x" {
+		t.Errorf("Expected a node without source to return its literal but got %q", detached.Source())
+	}
+}
+
+func TestWalk(t *testing.T) {
+	fmt.Println("TestWalk...")
+
+	root, _ := getTree(t)
+
+	kinds := []string{}
+	Walk(kindCollector{kinds: &kinds}, root)
+
+	expect := []string{
+		"Assign", ".Identifier", "end", ".Add", "..Number", "end", "..Call",
+		"...Identifier", "end", "...Number", "end", "...Identifier", "end", "...CloseParen", "end",
+		"end", "end", "end",
+	}
+
+	differ := &golex.Differ{}
+	differ.Compare(expect, kinds)
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+
+	identifiers := []string{}
+	Inspect(root, func(node *Node) bool {
+		if node == nil || node.Kind == "Call" {
+			return false
+		}
+
+		if node.Kind == "Identifier" {
+			identifiers = append(identifiers, node.Source())
+		}
+
+		return true
+	})
+
+	if len(identifiers) != 1 || identifiers[0] != "x" {
+		t.Errorf("Expected to only inspect x outside of the call but got %v", identifiers)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	fmt.Println("TestRewrite...")
+
+	root, _ := getTree(t)
+
+	// Remove the closing parenthesis and replace numbers by their doubled value
+	root = Rewrite(root, func(node *Node) *Node {
+		switch node.Kind {
+		case "CloseParen":
+			return nil
+		case "Number":
+			return &Node{Kind: "Doubled", Span: node.Span, Value: node.Value.(int) * 2}
+		}
+
+		return node
+	})
+
+	call := root.Children[1].Children[1]
+	if len(call.Children) != 3 || call.Children[1].Kind != "Doubled" || call.Children[1].Value != 4 {
+		t.Fatalf("Unexpected rewritten call %v", call.Children)
+	}
+
+	if doubled := call.Children[1]; doubled.Parent != call || doubled.Source() != "2" {
+		t.Errorf("Expected the replacement to be linked into the tree")
+	}
+
+	// The spans of the call and its ancestors no longer cover the removed parenthesis
+	if call.Span.End.Col != 15 || root.Children[1].Span.End.Col != 15 || root.Span.End.Col != 15 {
+		t.Errorf("Expected the spans to end at 1:15 but got %s, %s and %s", call.Span, root.Children[1].Span, root.Span)
+	}
+}
+
+func TestWalkNil(t *testing.T) {
+	fmt.Println("TestWalkNil...")
+
+	count := 0
+	Inspect(nil, func(node *Node) bool {
+		count++
+		return true
+	})
+
+	if count != 0 {
+		t.Errorf("Expected a nil tree not to be inspected but got %d calls", count)
+	}
+
+	// Nil children are skipped
+	root := &Node{Kind: "Root", Children: []*Node{nil, {Kind: "Leaf"}, nil}}
+
+	kinds := []Kind{}
+	Inspect(root, func(node *Node) bool {
+		if node != nil {
+			kinds = append(kinds, node.Kind)
+		}
+
+		return true
+	})
+
+	if len(kinds) != 2 || kinds[1] != "Leaf" {
+		t.Errorf("Expected Root and Leaf but got %v", kinds)
+	}
+}
+
+func TestPrinters(t *testing.T) {
+	fmt.Println("TestPrinters...")
+
+	source := "f(a)"
+	tokens := golextest.Tokenize(t, source)
+
+	root := NewNode("Call", NewLeaf("Identifier", tokens[0]), NewLeaf("Identifier", tokens[2])).SetSource(source)
+
+	if sexpr := SExpr(root); sexpr != `(Call (Identifier "f") (Identifier "a"))` {
+		t.Errorf("Unexpected S-expression %s", sexpr)
+	}
+
+	var sb strings.Builder
+	FprintTree(&sb, root)
+
+	expect := "Call 1:1-1:4\n" +
+		"├── Identifier \"f\" 1:1-1:2\n" +
+		"└── Identifier \"a\" 1:3-1:4\n"
+
+	if sb.String() != expect {
+		t.Errorf("Expected the tree:\n%s\nGot:\n%s", expect, sb.String())
+	}
+
+	sb.Reset()
+	FprintDOT(&sb, root)
+
+	expect = "digraph ast {\n" +
+		"\tnode [shape=box, fontname=\"monospace\"];\n" +
+		"\tn0 [label=\"Call\\n1:1-1:4\"];\n" +
+		"\tn1 [label=\"Identifier\\nf\\n1:1-1:2\"];\n" +
+		"\tn0 -> n1;\n" +
+		"\tn2 [label=\"Identifier\\na\\n1:3-1:4\"];\n" +
+		"\tn0 -> n2;\n" +
+		"}\n"
+
+	if sb.String() != expect {
+		t.Errorf("Expected the DOT graph:\n%s\nGot:\n%s", expect, sb.String())
+	}
+
+	sb.Reset()
+	FprintHTML(&sb, root, tokens)
+
+	// The parenthesis is not a node, but is annotated as a token of the call
+	if !strings.Contains(sb.String(), `<span class="token" data-type="OpenParenthesis" title="OpenParenthesis 1:2">(</span>`) {
+		t.Errorf("Expected the parenthesis token in the HTML but got:\n%s", sb.String())
+	}
+}
+
+func TestAppendEmptyChildren(t *testing.T) {
+	fmt.Println("TestAppendEmptyChildren...")
+
+	tokens := golextest.Tokenize(t, "a = 1")
+
+	// Nodes without children, like empty productions, have no span
+	node := NewNode("Assign", NewNode("Empty"), NewLeaf("Number", tokens[2]), NewNode("Empty"))
+	if node.Span != tokens[2].Span() || len(node.Children) != 3 {
+		t.Errorf("Expected the span of the number %s but got %s", tokens[2].Span(), node.Span)
+	}
+}
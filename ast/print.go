@@ -0,0 +1,198 @@
+package ast
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/cornejong/golex"
+)
+
+// printer writes formatted output, keeping the first error
+type printer struct {
+	w   io.Writer
+	err error
+	// token is the index of the next token the HTML printer looks at, the tokens are visited in order
+	token int
+}
+
+func (p *printer) printf(format string, args ...any) {
+	if p.err == nil {
+		_, p.err = fmt.Fprintf(p.w, format, args...)
+	}
+}
+
+// label returns the kind of the node followed by its literal for leaves, or the expected token type of missing nodes
+func label(node *Node) string {
+	if node.IsMissing() && node.Token.Literal == "" && node.Token.Type != nil {
+		return fmt.Sprintf("%s %s", node.Kind, node.Token.Type)
+	}
+
+	if node.IsLeaf() && node.Token.Literal != "" {
+		return fmt.Sprintf("%s %q", node.Kind, node.Token.Literal)
+	}
+
+	return string(node.Kind)
+}
+
+// ###################################################
+// #              S-expressions
+// ###################################################
+
+// SExpr returns the tree as an S-expression
+func SExpr(node *Node) string {
+	var sb strings.Builder
+	FprintSExpr(&sb, node)
+
+	return sb.String()
+}
+
+// FprintSExpr writes the tree as an S-expression, like (Call (Identifier "f") (Number "1"))
+func FprintSExpr(w io.Writer, node *Node) error {
+	p := &printer{w: w}
+	p.sexpr(node)
+
+	return p.err
+}
+
+func (p *printer) sexpr(node *Node) {
+	p.printf("(%s", label(node))
+
+	for _, child := range node.Children {
+		p.printf(" ")
+		p.sexpr(child)
+	}
+
+	p.printf(")")
+}
+
+// ###################################################
+// #              Indented tree
+// ###################################################
+
+// FprintTree writes the tree as indented text with the span of every node
+func FprintTree(w io.Writer, node *Node) error {
+	p := &printer{w: w}
+	p.printf("%s %s\n", label(node), node.Span)
+	p.tree(node, "")
+
+	return p.err
+}
+
+func (p *printer) tree(node *Node, indent string) {
+	for i, child := range node.Children {
+		branch, next := "├── ", "│   "
+		if i == len(node.Children)-1 {
+			branch, next = "└── ", "    "
+		}
+
+		p.printf("%s%s%s %s\n", indent, branch, label(child), child.Span)
+		p.tree(child, indent+next)
+	}
+}
+
+// ###################################################
+// #              Graphviz DOT
+// ###################################################
+
+// FprintDOT writes the tree as a Graphviz DOT digraph
+func FprintDOT(w io.Writer, node *Node) error {
+	p := &printer{w: w}
+	p.printf("digraph ast {\n")
+	p.printf("\tnode [shape=box, fontname=\"monospace\"];\n")
+
+	id := 0
+	var visit func(node *Node) int
+	visit = func(node *Node) int {
+		nodeID := id
+		id += 1
+
+		text := string(node.Kind)
+		if node.IsLeaf() && node.Token.Literal != "" {
+			text += "\n" + node.Token.Literal
+		}
+
+		p.printf("\tn%d [label=\"%s\"];\n", nodeID, dotEscape(text+"\n"+node.Span.String()))
+
+		for _, child := range node.Children {
+			p.printf("\tn%d -> n%d;\n", nodeID, visit(child))
+		}
+
+		return nodeID
+	}
+
+	visit(node)
+	p.printf("}\n")
+
+	return p.err
+}
+
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// ###################################################
+// #              HTML
+// ###################################################
+
+// FprintHTML writes the source covered by the tree as nested HTML spans, one per node, annotating
+// every node with the tokens it covers. The source is taken from the tree, see Node.SetSource.
+func FprintHTML(w io.Writer, node *Node, tokens golex.Tokens) error {
+	var source []rune
+	for n := node; n != nil && source == nil; n = n.Parent {
+		source = n.source
+	}
+
+	p := &printer{w: w}
+	p.printf("<style>.node{display:inline;outline:1px solid #ccc;padding:1px}.token{background:#eef}</style>\n")
+	p.printf("<pre class=\"golex-tree\">")
+	p.html(node, tokens, source)
+	p.printf("</pre>\n")
+
+	return p.err
+}
+
+func (p *printer) html(node *Node, tokens golex.Tokens, source []rune) {
+	p.printf("<span class=\"node\" data-kind=\"%s\" title=\"%s %s\">", html.EscapeString(string(node.Kind)), html.EscapeString(string(node.Kind)), node.Span)
+
+	cursor := node.Span.Start.Cursor
+	for _, child := range node.Children {
+		p.htmlTokens(cursor, child.Span.Start.Cursor, tokens, source)
+		p.html(child, tokens, source)
+		cursor = child.Span.End.Cursor
+	}
+
+	p.htmlTokens(cursor, node.Span.End.Cursor, tokens, source)
+	p.printf("</span>")
+}
+
+// htmlTokens writes the source between the offsets, wrapping the tokens in it
+func (p *printer) htmlTokens(start int, end int, tokens golex.Tokens, source []rune) {
+	text := func(from int, to int) string {
+		return html.EscapeString(golex.Span{Start: golex.Position{Cursor: from}, End: golex.Position{Cursor: to}}.Text(source))
+	}
+
+	cursor := start
+	for ; p.token < len(tokens); p.token++ {
+		token := tokens[p.token]
+		if token.End.Cursor > end {
+			break
+		}
+
+		if token.TypeIs(golex.TypeEof) || token.Position.Cursor < cursor {
+			continue
+		}
+
+		literal := text(token.Position.Cursor, token.End.Cursor)
+		if source == nil {
+			literal = html.EscapeString(token.Literal)
+		}
+
+		p.printf("%s<span class=\"token\" data-type=\"%s\" title=\"%s %d:%d\">%s</span>", text(cursor, token.Position.Cursor),
+			html.EscapeString(token.Type.String()), html.EscapeString(token.Type.String()), token.Position.Row, token.Position.Col, literal)
+		cursor = token.End.Cursor
+	}
+
+	p.printf("%s", text(cursor, end))
+}
@@ -0,0 +1,94 @@
+package ast
+
+import "github.com/cornejong/golex"
+
+// Visitor is called for every node by Walk. If the returned visitor w
+// is not nil, Walk visits the children of the node with w, followed by w.Visit(nil).
+type Visitor interface {
+	Visit(node *Node) (w Visitor)
+}
+
+// Walk traverses the tree in depth-first order, like go/ast.Walk.
+// Nil nodes, including nil children, are skipped.
+func Walk(v Visitor, node *Node) {
+	if node == nil {
+		return
+	}
+
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	for _, child := range node.Children {
+		Walk(v, child)
+	}
+
+	v.Visit(nil)
+}
+
+type inspector func(*Node) bool
+
+func (f inspector) Visit(node *Node) Visitor {
+	if f(node) {
+		return f
+	}
+
+	return nil
+}
+
+// Inspect traverses the tree in depth-first order, calling f for every node.
+// When f returns true the children of the node are inspected, followed by f(nil).
+func Inspect(node *Node, f func(*Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// Rewrite transforms the tree bottom-up. f is called for every node after its children are rewritten
+// and returns the replacement of the node, the node itself to keep it, or nil to remove it.
+// When children are replaced or removed the span of their parent is recomputed from the remaining children.
+// It returns the rewritten root.
+func Rewrite(node *Node, f func(*Node) *Node) *Node {
+	if node == nil {
+		return nil
+	}
+
+	changed := false
+	children := node.Children[:0]
+	for _, child := range node.Children {
+		var span golex.Span
+		if child != nil {
+			span = child.Span
+		}
+
+		// A kept child changes the span of the node when its own children changed
+		rewritten := Rewrite(child, f)
+		if rewritten != child || (rewritten != nil && rewritten.Span != span) {
+			changed = true
+		}
+
+		if rewritten != nil {
+			rewritten.Parent = node
+			children = append(children, rewritten)
+		}
+	}
+
+	clear(node.Children[len(children):])
+	node.Children = children
+
+	if changed {
+		node.Span = golex.Span{}
+		for _, child := range children {
+			node.extend(child)
+		}
+	}
+
+	parent := node.Parent
+	replacement := f(node)
+	if replacement != nil && replacement != node {
+		replacement.Parent = parent
+		if replacement.source == nil {
+			replacement.source = node.source
+		}
+	}
+
+	return replacement
+}
@@ -79,48 +79,143 @@ const (
 	AnyTokenType BuildInType = "AnyTokenType"
 )
 
+// buildInTypes lists all the build-in token types
+var buildInTypes []BuildInType = []BuildInType{
+	TypeSof, TypeEof, TypeInvalid,
+
+	TypeString, TypeDoubleQuoteString, TypeSingleQuoteString, TypeBacktickString, TypeTripleBacktickString,
+	TypeNumber, TypeInteger, TypeFloat, TypeBool, TypeNull, TypeNil,
+
+	TypeComment, TypeKeyword, TypeIdentifier, TypeSymbol,
+
+	TypePlus, TypeMinus, TypeMultiply, TypeDivide, TypeModulo, TypeAssign, TypeEqual, TypeNotEqual,
+	TypeLessThan, TypeGreaterThan, TypeLessThanOrEqual, TypeGreaterThanOrEqual, TypeAnd, TypeOr, TypeNot,
+
+	TypeOpenParen, TypeCloseParen, TypeOpenCurly, TypeCloseCurly, TypeOpenSquare, TypeCloseSquare,
+	TypeComma, TypeDot, TypeColon, TypeSemicolon,
+
+	TypeArrowRight, TypeArrowLeft, TypeQuestionMark, TypeTilde, TypeAmpersand, TypePipe, TypeCaret,
+	TypeDollar, TypeHash, TypeAt, TypeEllipses,
+
+	TypeSpace, TypeTab, TypeNewline, TypeCarriageReturn, TypeFormFeed,
+
+	AnyTokenType,
+}
+
+// buildInTypeInfo holds the metadata of the build-in token types
+var buildInTypeInfo map[TokenType]TokenTypeInfo = map[TokenType]TokenTypeInfo{
+	TypeSof:      {Category: CategorySpecial, DisplayName: "start of file"},
+	TypeEof:      {Category: CategorySpecial, DisplayName: "end of file"},
+	TypeInvalid:  {Category: CategorySpecial, DisplayName: "invalid token"},
+	AnyTokenType: {Category: CategorySpecial, DisplayName: "any token"},
+
+	TypeString:               {Category: CategoryLiteral, DisplayName: "string"},
+	TypeDoubleQuoteString:    {Category: CategoryLiteral, DisplayName: "double quoted string"},
+	TypeSingleQuoteString:    {Category: CategoryLiteral, DisplayName: "single quoted string"},
+	TypeBacktickString:       {Category: CategoryLiteral, DisplayName: "backtick string"},
+	TypeTripleBacktickString: {Category: CategoryLiteral, DisplayName: "triple backtick string"},
+	TypeNumber:               {Category: CategoryLiteral, DisplayName: "number"},
+	TypeInteger:              {Category: CategoryLiteral, DisplayName: "integer"},
+	TypeFloat:                {Category: CategoryLiteral, DisplayName: "float"},
+	TypeBool:                 {Category: CategoryLiteral, DisplayName: "boolean"},
+	TypeNull:                 {Category: CategoryLiteral, DisplayName: "null"},
+	TypeNil:                  {Category: CategoryLiteral, DisplayName: "nil"},
+
+	TypeComment: {Category: CategoryComment, DisplayName: "comment"},
+
+	TypeKeyword: {Category: CategoryKeyword, DisplayName: "keyword"},
+
+	TypeIdentifier: {Category: CategoryIdentifier, DisplayName: "identifier"},
+	TypeSymbol:     {Category: CategoryIdentifier, DisplayName: "symbol"},
+
+	TypePlus:               {Category: CategoryOperator, DisplayName: "'+'"},
+	TypeMinus:              {Category: CategoryOperator, DisplayName: "'-'"},
+	TypeMultiply:           {Category: CategoryOperator, DisplayName: "'*'"},
+	TypeDivide:             {Category: CategoryOperator, DisplayName: "'/'"},
+	TypeModulo:             {Category: CategoryOperator, DisplayName: "'%'"},
+	TypeAssign:             {Category: CategoryOperator, DisplayName: "'='"},
+	TypeEqual:              {Category: CategoryOperator, DisplayName: "'=='"},
+	TypeNotEqual:           {Category: CategoryOperator, DisplayName: "'!='"},
+	TypeLessThan:           {Category: CategoryOperator, DisplayName: "'<'"},
+	TypeGreaterThan:        {Category: CategoryOperator, DisplayName: "'>'"},
+	TypeLessThanOrEqual:    {Category: CategoryOperator, DisplayName: "'<='"},
+	TypeGreaterThanOrEqual: {Category: CategoryOperator, DisplayName: "'>='"},
+	TypeAnd:                {Category: CategoryOperator, DisplayName: "'&&'"},
+	TypeOr:                 {Category: CategoryOperator, DisplayName: "'||'"},
+	TypeNot:                {Category: CategoryOperator, DisplayName: "'!'"},
+	TypeArrowRight:         {Category: CategoryOperator, DisplayName: "'->'"},
+	TypeArrowLeft:          {Category: CategoryOperator, DisplayName: "'<-'"},
+	TypeQuestionMark:       {Category: CategoryOperator, DisplayName: "'?'"},
+	TypeTilde:              {Category: CategoryOperator, DisplayName: "'~'"},
+	TypeAmpersand:          {Category: CategoryOperator, DisplayName: "'&'"},
+	TypePipe:               {Category: CategoryOperator, DisplayName: "'|'"},
+	TypeCaret:              {Category: CategoryOperator, DisplayName: "'^'"},
+
+	TypeComma:     {Category: CategoryPunctuation, DisplayName: "','"},
+	TypeDot:       {Category: CategoryPunctuation, DisplayName: "'.'"},
+	TypeColon:     {Category: CategoryPunctuation, DisplayName: "':'"},
+	TypeSemicolon: {Category: CategoryPunctuation, DisplayName: "';'"},
+	TypeDollar:    {Category: CategoryPunctuation, DisplayName: "'$'"},
+	TypeHash:      {Category: CategoryPunctuation, DisplayName: "'#'"},
+	TypeAt:        {Category: CategoryPunctuation, DisplayName: "'@'"},
+	TypeEllipses:  {Category: CategoryPunctuation, DisplayName: "'...'"},
+
+	TypeSpace:          {Category: CategoryWhitespace, DisplayName: "space"},
+	TypeTab:            {Category: CategoryWhitespace, DisplayName: "tab"},
+	TypeNewline:        {Category: CategoryWhitespace, DisplayName: "newline"},
+	TypeCarriageReturn: {Category: CategoryWhitespace, DisplayName: "carriage return"},
+	TypeFormFeed:       {Category: CategoryWhitespace, DisplayName: "form feed"},
+
+	TypeOpenParen:   {Category: CategoryBracket, DisplayName: "'('", ClosedBy: TypeCloseParen},
+	TypeCloseParen:  {Category: CategoryBracket, DisplayName: "')'", OpenedBy: TypeOpenParen},
+	TypeOpenCurly:   {Category: CategoryBracket, DisplayName: "'{'", ClosedBy: TypeCloseCurly},
+	TypeCloseCurly:  {Category: CategoryBracket, DisplayName: "'}'", OpenedBy: TypeOpenCurly},
+	TypeOpenSquare:  {Category: CategoryBracket, DisplayName: "'['", ClosedBy: TypeCloseSquare},
+	TypeCloseSquare: {Category: CategoryBracket, DisplayName: "']'", OpenedBy: TypeOpenSquare},
+}
+
 var buildInLiteralTokens []LiteralToken = []LiteralToken{
-	LiteralToken{TypeEllipses, "..."},
-	LiteralToken{TypeOpenCurly, "{"},
-	LiteralToken{TypeCloseCurly, "}"},
-	LiteralToken{TypeOpenParen, "("},
-	LiteralToken{TypeCloseParen, ")"},
-	LiteralToken{TypeOpenSquare, "["},
-	LiteralToken{TypeCloseSquare, "]"},
-	LiteralToken{TypeComma, ","},
-	LiteralToken{TypeDot, "."},
-	LiteralToken{TypeColon, ":"},
-	LiteralToken{TypeSemicolon, ";"},
-	LiteralToken{TypePlus, "+"},
-	LiteralToken{TypeMinus, "-"},
-	LiteralToken{TypeMultiply, "*"},
-	LiteralToken{TypeDivide, "/"},
-	LiteralToken{TypeModulo, "%"},
-	LiteralToken{TypeAssign, "="},
-	LiteralToken{TypeEqual, "=="},
-	LiteralToken{TypeNotEqual, "!="},
-	LiteralToken{TypeLessThan, "<"},
-	LiteralToken{TypeGreaterThan, ">"},
-	LiteralToken{TypeLessThanOrEqual, "<="},
-	LiteralToken{TypeGreaterThanOrEqual, ">="},
-	LiteralToken{TypeAnd, "&&"},
-	LiteralToken{TypeOr, "||"},
-	LiteralToken{TypeNot, "!"},
-	LiteralToken{TypeArrowRight, "->"},
-	LiteralToken{TypeArrowLeft, "<-"},
-	LiteralToken{TypeQuestionMark, "?"},
-	LiteralToken{TypeTilde, "~"},
-	LiteralToken{TypeAmpersand, "&"},
-	LiteralToken{TypePipe, "|"},
-	LiteralToken{TypeCaret, "^"},
-	LiteralToken{TypeDollar, "$"},
-	LiteralToken{TypeHash, "#"},
-	LiteralToken{TypeAt, "@"},
-
-	LiteralToken{TypeSpace, " "},
-	LiteralToken{TypeTab, "\t"},
-	LiteralToken{TypeNewline, "\n"},
-	LiteralToken{TypeCarriageReturn, "\r"},
-	LiteralToken{TypeFormFeed, "\f"},
-	LiteralToken{TypeEof, string(EOF)},
+	LiteralToken{Type: TypeEllipses, Literal: "..."},
+	LiteralToken{Type: TypeOpenCurly, Literal: "{"},
+	LiteralToken{Type: TypeCloseCurly, Literal: "}"},
+	LiteralToken{Type: TypeOpenParen, Literal: "("},
+	LiteralToken{Type: TypeCloseParen, Literal: ")"},
+	LiteralToken{Type: TypeOpenSquare, Literal: "["},
+	LiteralToken{Type: TypeCloseSquare, Literal: "]"},
+	LiteralToken{Type: TypeComma, Literal: ","},
+	LiteralToken{Type: TypeDot, Literal: "."},
+	LiteralToken{Type: TypeColon, Literal: ":"},
+	LiteralToken{Type: TypeSemicolon, Literal: ";"},
+	LiteralToken{Type: TypePlus, Literal: "+"},
+	LiteralToken{Type: TypeMinus, Literal: "-"},
+	LiteralToken{Type: TypeMultiply, Literal: "*"},
+	LiteralToken{Type: TypeDivide, Literal: "/"},
+	LiteralToken{Type: TypeModulo, Literal: "%"},
+	LiteralToken{Type: TypeAssign, Literal: "="},
+	LiteralToken{Type: TypeEqual, Literal: "=="},
+	LiteralToken{Type: TypeNotEqual, Literal: "!="},
+	LiteralToken{Type: TypeLessThan, Literal: "<"},
+	LiteralToken{Type: TypeGreaterThan, Literal: ">"},
+	LiteralToken{Type: TypeLessThanOrEqual, Literal: "<="},
+	LiteralToken{Type: TypeGreaterThanOrEqual, Literal: ">="},
+	LiteralToken{Type: TypeAnd, Literal: "&&"},
+	LiteralToken{Type: TypeOr, Literal: "||"},
+	LiteralToken{Type: TypeNot, Literal: "!"},
+	LiteralToken{Type: TypeArrowRight, Literal: "->"},
+	LiteralToken{Type: TypeArrowLeft, Literal: "<-"},
+	LiteralToken{Type: TypeQuestionMark, Literal: "?"},
+	LiteralToken{Type: TypeTilde, Literal: "~"},
+	LiteralToken{Type: TypeAmpersand, Literal: "&"},
+	LiteralToken{Type: TypePipe, Literal: "|"},
+	LiteralToken{Type: TypeCaret, Literal: "^"},
+	LiteralToken{Type: TypeDollar, Literal: "$"},
+	LiteralToken{Type: TypeHash, Literal: "#"},
+	LiteralToken{Type: TypeAt, Literal: "@"},
+
+	LiteralToken{Type: TypeSpace, Literal: " "},
+	LiteralToken{Type: TypeTab, Literal: "\t"},
+	LiteralToken{Type: TypeNewline, Literal: "\n"},
+	LiteralToken{Type: TypeCarriageReturn, Literal: "\r"},
+	LiteralToken{Type: TypeFormFeed, Literal: "\f"},
+	LiteralToken{Type: TypeEof, Literal: string(EOF)},
 }
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/cornejong/golex"
+)
+
+const (
+	formatTable  = "table"
+	formatJSON   = "json"
+	formatNDJSON = "ndjson"
+)
+
+// source is a single named input of the lexer
+type source struct {
+	Name    string
+	Content string
+}
+
+// tokenRecord is the JSON representation of a token in the json and ndjson output
+type tokenRecord struct {
+	File  string      `json:"file"`
+	Token golex.Token `json:"token"`
+}
+
+func runLex(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("lex", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	preset := flags.String("preset", "default", "lexer preset to use ("+strings.Join(presetNames(), ", ")+")")
+	specFile := flags.String("spec", "", "path to a JSON lexer spec file")
+	format := flags.String("format", formatTable, "output format (table, json, ndjson)")
+	only := flags.String("only", "", "comma separated list of token types to print")
+	exclude := flags.String("exclude", "", "comma separated list of token types to omit")
+	color := flags.Bool("color", false, "render lexing errors as colored diagnostics")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	if !slices.Contains([]string{formatTable, formatJSON, formatNDJSON}, *format) {
+		fmt.Fprintf(stderr, "golex: unknown format %q\n", *format)
+		return 2
+	}
+
+	lexer, err := buildLexer(*preset, *specFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "golex: %s\n", err)
+		return 2
+	}
+
+	sources, err := readSources(flags.Args(), stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "golex: %s\n", err)
+		return 2
+	}
+
+	filter := newTypeFilter(*only, *exclude)
+	records := []tokenRecord{}
+
+	encoder := json.NewEncoder(stdout)
+	encoder.SetEscapeHTML(false)
+	status := 0
+
+	for _, src := range sources {
+		for token, err := range lexer.IterateNamed(src.Name, src.Content) {
+			if err != nil {
+				if printError(stderr, err, *color) != nil {
+					return 1
+				}
+
+				status = 1
+				break
+			}
+
+			if !filter.Match(token) {
+				continue
+			}
+
+			switch *format {
+			case formatTable:
+				token.Fdump(stdout)
+			case formatNDJSON:
+				if err := encoder.Encode(newTokenRecord(src.Name, token)); err != nil {
+					fmt.Fprintf(stderr, "golex: %s\n", err)
+					return 1
+				}
+			case formatJSON:
+				records = append(records, newTokenRecord(src.Name, token))
+			}
+		}
+	}
+
+	if *format == formatJSON {
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(records); err != nil {
+			fmt.Fprintf(stderr, "golex: %s\n", err)
+			return 1
+		}
+	}
+
+	return status
+}
+
+// printError prints the lexing error, as a colored diagnostic when requested.
+// It returns the error of writing to w.
+func printError(w io.Writer, err error, color bool) error {
+	var lexErr *golex.Error
+	if color && errors.As(err, &lexErr) {
+		return golex.DiagnosticRenderer{Color: true}.Render(w, lexErr.Diagnostic())
+	}
+
+	_, werr := fmt.Fprintln(w, err)
+	return werr
+}
+
+func newTokenRecord(file string, token golex.Token) tokenRecord {
+	return tokenRecord{File: file, Token: token}
+}
+
+func readSources(paths []string, stdin io.Reader) ([]source, error) {
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+
+	sources := []source{}
+	for _, path := range paths {
+		var content []byte
+		var err error
+
+		if path == "-" {
+			content, err = io.ReadAll(stdin)
+			path = "<stdin>"
+		} else {
+			content, err = os.ReadFile(path)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		sources = append(sources, source{Name: path, Content: string(content)})
+	}
+
+	return sources, nil
+}
+
+// typeFilter decides which tokens are printed based on their type names
+type typeFilter struct {
+	only    []string
+	exclude []string
+}
+
+func newTypeFilter(only string, exclude string) typeFilter {
+	return typeFilter{only: splitList(only), exclude: splitList(exclude)}
+}
+
+func (f typeFilter) Match(token golex.Token) bool {
+	name := token.Type.String()
+
+	if len(f.only) > 0 && !slices.Contains(f.only, name) {
+		return false
+	}
+
+	return !slices.Contains(f.exclude, name)
+}
+
+func splitList(list string) []string {
+	items := []string{}
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cornejong/golex"
+	"github.com/cornejong/golex/ebnf"
+	"github.com/cornejong/golex/ll1"
+)
+
+// runLL1 checks that EBNF grammars are LL(1), printing every conflict of their predictive tables
+func runLL1(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("ll1", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	start := flags.String("start", "", "start production, defaults to the first production of the grammar")
+	sets := flags.Bool("sets", false, "print the FIRST and FOLLOW sets of the nonterminals")
+	preset := flags.String("preset", "default", "lexer preset the literals are lexed with ("+strings.Join(presetNames(), ", ")+")")
+	specFile := flags.String("spec", "", "path to a JSON lexer spec file the literals are lexed with")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	// The lexer decides which token types the literals of the grammar overlap, like keywords and symbols
+	options, err := lexerOptions(*preset, *specFile)
+	if err == nil {
+		err = golex.NewLexer(options...).Err()
+	}
+
+	if err != nil {
+		fmt.Fprintf(stderr, "golex: %s\n", err)
+		return 2
+	}
+
+	sources, err := readSources(flags.Args(), stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "golex: %s\n", err)
+		return 2
+	}
+
+	status := 0
+	for _, src := range sources {
+		grammar, err := ebnf.Parse(src.Name, src.Content)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			status = 1
+			continue
+		}
+
+		name := *start
+		if name == "" && len(grammar.Names) > 0 {
+			name = grammar.Names[0]
+		}
+
+		g, err := ll1.FromEBNF(grammar, name)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			status = 1
+			continue
+		}
+
+		table, err := ll1.Build(g.LexWith(options...))
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			status = 1
+			continue
+		}
+
+		if *sets {
+			for _, nonterminal := range g.Nonterminals() {
+				fmt.Fprintf(stdout, "%s\n  FIRST  %s\n  FOLLOW %s\n", nonterminal, table.First[nonterminal], table.Follow[nonterminal])
+			}
+		}
+
+		for _, conflict := range table.Conflicts {
+			fmt.Fprintf(stdout, "%s: %s\n", src.Name, conflict)
+		}
+
+		if !table.IsLL1() {
+			status = 1
+			continue
+		}
+
+		fmt.Fprintf(stdout, "%s: %s is LL(1)\n", src.Name, name)
+	}
+
+	return status
+}
@@ -0,0 +1,61 @@
+// Command golex tokenizes files or stdin using one of the built-in
+// presets or a JSON lexer spec and prints the resulting tokens.
+// The parse command prints the tree of the tokens nested by their brackets,
+// or the tree of the tokens parsed with an EBNF grammar.
+// The ll1 command checks that EBNF grammars are LL(1) and reports their conflicts.
+//
+// Usage:
+//
+//	golex [lex] [flags] [file ...]
+//	golex parse [-format sexpr|tree|dot|html] [-dot] [-ebnf grammar [-start production]] [flags] [file ...]
+//	golex ll1 [-start production] [-sets] [-preset name] [-spec file] [grammar ...]
+//
+// When no files are given, or a file is "-", the source is read from stdin.
+// The exit status is 1 when any of the sources fails to lex and 2 on usage errors.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+type command func(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int
+
+var commands = map[string]command{
+	"lex":   runLex,
+	"parse": runParse,
+	"ll1":   runLL1,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) > 0 {
+		if cmd, ok := commands[args[0]]; ok {
+			return cmd(args[1:], stdin, stdout, stderr)
+		}
+
+		if args[0] == "help" {
+			usage(stdout)
+			return 0
+		}
+	}
+
+	// Lexing is the default command
+	return runLex(args, stdin, stdout, stderr)
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: golex [command] [flags] [file ...]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "commands:")
+	fmt.Fprintln(w, "  lex     tokenize the input and print the tokens (default)")
+	fmt.Fprintln(w, "  parse   print the tree of the tokens nested by their brackets or parsed with an EBNF grammar")
+	fmt.Fprintln(w, "  ll1     check that EBNF grammars are LL(1) and print their conflicts")
+	fmt.Fprintln(w, "  help    print this message")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "run 'golex <command> -h' for the flags of a command")
+}
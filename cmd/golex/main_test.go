@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// commandCase runs a command with the files written to a temporary directory,
+// {dir} in the arguments is replaced by the path of that directory
+type commandCase struct {
+	name   string
+	args   []string
+	stdin  string
+	files  map[string]string
+	status int
+	stdout []string
+	stderr []string
+	// absent holds strings the stdout must not contain
+	absent []string
+}
+
+func runCommandCases(t *testing.T, cmd command, cases []commandCase) {
+	t.Helper()
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range c.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			args := []string{}
+			for _, arg := range c.args {
+				args = append(args, strings.ReplaceAll(arg, "{dir}", dir))
+			}
+
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			status := cmd(args, strings.NewReader(c.stdin), stdout, stderr)
+
+			if status != c.status {
+				t.Errorf("Expected exit status %d but got %d, stderr: %s", c.status, status, stderr)
+			}
+
+			for _, expected := range c.stdout {
+				if !strings.Contains(stdout.String(), expected) {
+					t.Errorf("Expected stdout to contain %q but got:\n%s", expected, stdout)
+				}
+			}
+
+			for _, expected := range c.stderr {
+				if !strings.Contains(stderr.String(), expected) {
+					t.Errorf("Expected stderr to contain %q but got:\n%s", expected, stderr)
+				}
+			}
+
+			for _, unexpected := range c.absent {
+				if strings.Contains(stdout.String(), unexpected) {
+					t.Errorf("Expected stdout not to contain %q but got:\n%s", unexpected, stdout)
+				}
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	fmt.Println("TestRun...")
+
+	runCommandCases(t, run, []commandCase{
+		{name: "default command", stdin: "a", stdout: []string{"Symbol", "EndOfFile"}},
+		{name: "lex command", args: []string{"lex"}, stdin: "a", stdout: []string{"Symbol"}},
+		{name: "parse command", args: []string{"parse", "-format", "sexpr"}, stdin: "(a)", stdout: []string{"(Group"}},
+		{name: "ll1 command", args: []string{"ll1"}, stdin: `A = "x" .`, stdout: []string{"A is LL(1)"}},
+		{name: "help", args: []string{"help"}, stdout: []string{"usage: golex"}},
+		{name: "unknown flag", args: []string{"-nope"}, status: 2},
+	})
+}
+
+func TestRunLex(t *testing.T) {
+	fmt.Println("TestRunLex...")
+
+	runCommandCases(t, runLex, []commandCase{
+		{name: "table", stdin: "a = 1", stdout: []string{"Symbol", "Assign", "Integer", "EndOfFile"}},
+		{name: "json", args: []string{"-format", "json"}, stdin: "a", stdout: []string{"[\n", `"file": "<stdin>"`, `"type": "Symbol"`}},
+		{name: "ndjson", args: []string{"-format", "ndjson"}, stdin: "a", stdout: []string{`{"file":"<stdin>","token":{"type":"Symbol"`}},
+		{name: "unknown format", args: []string{"-format", "xml"}, status: 2, stderr: []string{`unknown format "xml"`}},
+		{name: "help", args: []string{"-h"}},
+		{
+			name: "only", args: []string{"-only", "Symbol"}, stdin: "a = 1",
+			stdout: []string{"Symbol"}, absent: []string{"Assign", "Integer", "EndOfFile"},
+		},
+		{
+			name: "exclude", args: []string{"-exclude", "Symbol, EndOfFile"}, stdin: "a = 1",
+			stdout: []string{"Assign", "Integer"}, absent: []string{"Symbol", "EndOfFile"},
+		},
+		{name: "preset", args: []string{"-preset", "go"}, stdin: "func", stdout: []string{"Keyword"}},
+		{name: "unknown preset", args: []string{"-preset", "cobol"}, status: 2, stderr: []string{`unknown preset "cobol"`}},
+		{
+			name: "files", args: []string{"{dir}/a.txt", "{dir}/b.txt"}, files: map[string]string{"a.txt": "a", "b.txt": "b"},
+			stdout: []string{" a ", " b "},
+		},
+		{name: "missing file", args: []string{"{dir}/missing.txt"}, status: 2},
+		{name: "lexing error", stdin: `a "b`, status: 1, stdout: []string{"Symbol"}, stderr: []string{"<stdin>:1:3", "Unterminated string"}},
+		{name: "colored lexing error", args: []string{"-color"}, stdin: `a "b`, status: 1, stderr: []string{"\x1b[", "L0002"}},
+		{
+			name: "spec", args: []string{"-spec", "{dir}/spec.json"}, stdin: "let f => # comment\nx",
+			files: map[string]string{"spec.json": `{
+				"types": ["FatArrow"],
+				"keywords": ["let"],
+				"literals": [{"type": "FatArrow", "literal": "=>"}],
+				"comments": [{"opener": "#"}],
+				"ignoreComments": true
+			}`},
+			stdout: []string{"Keyword", "FatArrow"}, absent: []string{"Comment"},
+		},
+		{
+			name: "spec preset", args: []string{"-spec", "{dir}/spec.json"}, stdin: "# comment",
+			files: map[string]string{"spec.json": `{"preset": "shell"}`}, stdout: []string{"Comment"},
+		},
+		{
+			name: "spec without literals", args: []string{"-spec", "{dir}/spec.json"}, stdin: ";",
+			files: map[string]string{"spec.json": `{"withoutLiterals": ["Semicolon"]}`}, status: 1,
+		},
+		{
+			name: "spec string type", args: []string{"-spec", "{dir}/spec.json"}, stdin: "`a`",
+			files:  map[string]string{"spec.json": `{"strings": [{"type": "BacktickString", "enclosure": "` + "`" + `"}]}`},
+			stdout: []string{"BacktickString"},
+		},
+		{name: "missing spec", args: []string{"-spec", "{dir}/missing.json"}, status: 2},
+		{
+			name: "malformed spec", args: []string{"-spec", "{dir}/spec.json"},
+			files: map[string]string{"spec.json": `{"keywords": `}, status: 2, stderr: []string{"invalid spec"},
+		},
+		{
+			name: "unknown preset in spec", args: []string{"-spec", "{dir}/spec.json"},
+			files: map[string]string{"spec.json": `{"preset": "cobol"}`}, status: 2, stderr: []string{`unknown preset "cobol"`},
+		},
+		{
+			name: "undeclared literal type", args: []string{"-spec", "{dir}/spec.json"},
+			files:  map[string]string{"spec.json": `{"literals": [{"type": "FatArow", "literal": "=>"}]}`},
+			status: 2, stderr: []string{`unknown token type "FatArow"`},
+		},
+		{
+			name: "unknown removed literal", args: []string{"-spec", "{dir}/spec.json"},
+			files:  map[string]string{"spec.json": `{"withoutLiterals": ["Semicolons"]}`},
+			status: 2, stderr: []string{`unknown token type "Semicolons"`},
+		},
+		{
+			name: "literal without type", args: []string{"-spec", "{dir}/spec.json"},
+			files:  map[string]string{"spec.json": `{"literals": [{"literal": "=>"}]}`},
+			status: 2, stderr: []string{"require a type and a literal"},
+		},
+		{
+			name: "comment without opener", args: []string{"-spec", "{dir}/spec.json"},
+			files: map[string]string{"spec.json": `{"comments": [{"closer": "*/"}]}`}, status: 2,
+		},
+		{
+			name: "string without enclosure", args: []string{"-spec", "{dir}/spec.json"},
+			files: map[string]string{"spec.json": `{"strings": [{"type": "String"}]}`}, status: 2,
+		},
+		{
+			name: "malformed symbol map", args: []string{"-spec", "{dir}/spec.json"},
+			files:  map[string]string{"spec.json": `{"symbolStart": "z-a"}`},
+			status: 2, stderr: []string{"invalid symbol character map"},
+		},
+	})
+}
+
+func TestRunParse(t *testing.T) {
+	fmt.Println("TestRunParse...")
+
+	runCommandCases(t, runParse, []commandCase{
+		{name: "tree", stdin: "a (b)", stdout: []string{"Source", "Group", `OpenParenthesis "("`}},
+		{
+			name: "sexpr", args: []string{"-format", "sexpr"}, stdin: "a (b)",
+			stdout: []string{`(Source (Symbol "a") (Group (OpenParenthesis "(") (Symbol "b") (CloseParenthesis ")")))`},
+		},
+		{name: "dot", args: []string{"-format", "dot"}, stdin: "(a)", stdout: []string{"digraph"}},
+		{name: "dot flag", args: []string{"-dot"}, stdin: "(a)", stdout: []string{"digraph"}},
+		{name: "html", args: []string{"-format", "html"}, stdin: "(a)", stdout: []string{"<span"}},
+		{name: "unknown format", args: []string{"-format", "xml"}, status: 2},
+		{name: "unknown preset", args: []string{"-preset", "cobol"}, status: 2},
+		{
+			name: "bad spec", args: []string{"-spec", "{dir}/spec.json"},
+			files: map[string]string{"spec.json": `[]`}, status: 2, stderr: []string{"invalid spec"},
+		},
+		{name: "unclosed bracket", stdin: "a (b", status: 1, stderr: []string{"<stdin>:1:3", "unclosed '('"}},
+		{name: "unmatched bracket", stdin: "a (b]", status: 1, stderr: []string{"unmatched"}},
+		{name: "lexing error", stdin: `"a`, status: 1, stderr: []string{"Unterminated string"}},
+		{
+			name: "bad source first", args: []string{"-format", "sexpr", "{dir}/bad.dsl", "{dir}/good.dsl"}, status: 1,
+			files:  map[string]string{"bad.dsl": "a (b", "good.dsl": "(c)"},
+			stdout: []string{`(Source (Group (OpenParenthesis "(") (Symbol "c") (CloseParenthesis ")")))`}, stderr: []string{"unclosed '('"},
+		},
+		{
+			name: "lexing error first", args: []string{"-format", "sexpr", "{dir}/bad.dsl", "{dir}/good.dsl"}, status: 1,
+			files:  map[string]string{"bad.dsl": `"a`, "good.dsl": "(c)"},
+			stdout: []string{`(Symbol "c")`}, stderr: []string{"Unterminated string"},
+		},
+		{
+			name: "ebnf", args: []string{"-ebnf", "{dir}/calc.ebnf", "-format", "sexpr"}, stdin: "1 + 2",
+			files:  map[string]string{"calc.ebnf": `Sum = Integer { "+" Integer } .`},
+			stdout: []string{`(Sum (Integer "1") (Plus "+") (Integer "2"))`},
+		},
+		{
+			name: "ebnf start", args: []string{"-ebnf", "{dir}/calc.ebnf", "-start", "Value", "-format", "sexpr"}, stdin: "a",
+			files:  map[string]string{"calc.ebnf": `Sum = Value { "+" Value } . Value = Integer | Symbol .`},
+			stdout: []string{`(Value (Symbol "a"))`}, absent: []string{"Sum"},
+		},
+		{
+			name: "ebnf syntax error", args: []string{"-ebnf", "{dir}/calc.ebnf"}, stdin: "1 +",
+			files: map[string]string{"calc.ebnf": `Sum = Integer { "+" Integer } .`}, status: 1, stderr: []string{"expected integer"},
+		},
+		{
+			name: "undefined start", args: []string{"-ebnf", "{dir}/calc.ebnf", "-start", "Product"},
+			files: map[string]string{"calc.ebnf": `Sum = Integer .`}, status: 2, stderr: []string{"Product"},
+		},
+		{name: "missing grammar", args: []string{"-ebnf", "{dir}/missing.ebnf"}, status: 2},
+		{name: "start without grammar", args: []string{"-start", "Sum"}, status: 2, stderr: []string{"requires an -ebnf grammar"}},
+	})
+}
+
+func TestRunLL1(t *testing.T) {
+	fmt.Println("TestRunLL1...")
+
+	runCommandCases(t, runLL1, []commandCase{
+		{name: "ll1", stdin: `A = "x" | "y" .`, stdout: []string{"<stdin>: A is LL(1)"}},
+		{
+			name: "start", args: []string{"-start", "B"}, stdin: `A = "x" . B = A | "y" .`,
+			stdout: []string{"B is LL(1)"}, absent: []string{"A is LL(1)"},
+		},
+		{name: "sets", args: []string{"-sets"}, stdin: `A = "x" .`, stdout: []string{"FIRST", "FOLLOW"}},
+		{name: "conflict", stdin: `A = "x" | "x" .`, status: 1, stdout: []string{`LL(1) conflict: A on "x"`}},
+		{name: "literal conflict", stdin: `A = "let" Symbol | Symbol .`, status: 1, stdout: []string{`LL(1) conflict: A on "let"`}},
+		{
+			name: "keyword", args: []string{"-spec", "{dir}/spec.json"}, stdin: `A = "let" Symbol | Symbol .`,
+			files: map[string]string{"spec.json": `{"keywords": ["let"]}`}, stdout: []string{"A is LL(1)"},
+		},
+		{name: "preset", args: []string{"-preset", "go"}, stdin: `A = "func" Symbol | Symbol .`, stdout: []string{"A is LL(1)"}},
+		{name: "unknown preset", args: []string{"-preset", "cobol"}, stdin: `A = "x" .`, status: 2},
+		{name: "syntax error", stdin: `A = "x" `, status: 1},
+		{name: "undefined start", args: []string{"-start", "B"}, stdin: `A = "x" .`, status: 1},
+		{
+			name: "files", args: []string{"{dir}/a.ebnf", "{dir}/b.ebnf"}, status: 1,
+			files:  map[string]string{"a.ebnf": `A = "x" .`, "b.ebnf": `B = "y" | "y" .`},
+			stdout: []string{"a.ebnf: A is LL(1)", "b.ebnf: LL(1) conflict"},
+		},
+		{name: "missing file", args: []string{"{dir}/missing.ebnf"}, status: 2},
+		{name: "unknown flag", args: []string{"-nope"}, status: 2},
+	})
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/cornejong/golex"
+	"github.com/cornejong/golex/ast"
+	"github.com/cornejong/golex/ebnf"
+)
+
+const (
+	formatSExpr = "sexpr"
+	formatTree  = "tree"
+	formatDOT   = "dot"
+	formatHTML  = "html"
+)
+
+func runParse(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("parse", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	preset := flags.String("preset", "default", "lexer preset to use ("+strings.Join(presetNames(), ", ")+")")
+	specFile := flags.String("spec", "", "path to a JSON lexer spec file")
+	format := flags.String("format", formatTree, "output format (sexpr, tree, dot, html)")
+	dot := flags.Bool("dot", false, "print the tree as Graphviz DOT, short for -format dot")
+	color := flags.Bool("color", false, "render errors as colored diagnostics")
+	grammarFile := flags.String("ebnf", "", "path to an EBNF grammar to parse the sources with, by default the tokens are nested by their brackets")
+	start := flags.String("start", "", "start production of the -ebnf grammar, defaults to its first production")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	if *dot {
+		*format = formatDOT
+	}
+
+	if !slices.Contains([]string{formatSExpr, formatTree, formatDOT, formatHTML}, *format) {
+		fmt.Fprintf(stderr, "golex: unknown format %q\n", *format)
+		return 2
+	}
+
+	if *start != "" && *grammarFile == "" {
+		fmt.Fprintln(stderr, "golex: -start requires an -ebnf grammar")
+		return 2
+	}
+
+	lexer, err := buildLexer(*preset, *specFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "golex: %s\n", err)
+		return 2
+	}
+
+	buildTree := bracketTree
+	if *grammarFile != "" {
+		buildTree, err = grammarTree(*grammarFile, *start)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+	}
+
+	sources, err := readSources(flags.Args(), stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "golex: %s\n", err)
+		return 2
+	}
+
+	status := 0
+	for _, src := range sources {
+		failed := false
+		tokens := golex.Tokens{}
+		for token, err := range lexer.IterateNamed(src.Name, src.Content) {
+			if err != nil {
+				if printError(stderr, err, *color) != nil {
+					return 1
+				}
+
+				failed = true
+				break
+			}
+
+			tokens = append(tokens, token)
+		}
+
+		if failed {
+			status = 1
+			continue
+		}
+
+		tree, err := buildTree(tokens, src.Content)
+		if err != nil {
+			if printError(stderr, err, *color) != nil {
+				return 1
+			}
+
+			status = 1
+			continue
+		}
+
+		tree.SetSource(src.Content)
+
+		switch *format {
+		case formatSExpr:
+			err = ast.FprintSExpr(stdout, tree)
+			fmt.Fprintln(stdout)
+		case formatTree:
+			err = ast.FprintTree(stdout, tree)
+		case formatDOT:
+			err = ast.FprintDOT(stdout, tree)
+		case formatHTML:
+			err = ast.FprintHTML(stdout, tree, tokens)
+		}
+
+		if err != nil {
+			fmt.Fprintf(stderr, "golex: %s\n", err)
+			return 1
+		}
+	}
+
+	return status
+}
+
+// grammarTree reads the EBNF grammar from the file and returns a function parsing the tokens
+// as its start production, or its first production when no start is given
+func grammarTree(path string, start string) (func(golex.Tokens, string) (*ast.Node, error), error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("golex: %w", err)
+	}
+
+	grammar, err := ebnf.Parse(path, string(content))
+	if err != nil {
+		return nil, err
+	}
+
+	if start == "" && len(grammar.Names) > 0 {
+		start = grammar.Names[0]
+	}
+
+	parser, err := grammar.Parser(start)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(tokens golex.Tokens, source string) (*ast.Node, error) {
+		return parser.Parse(tokens)
+	}, nil
+}
+
+// bracketTree builds a tree of the tokens, nesting them in a Group node for every pair of brackets
+func bracketTree(tokens golex.Tokens, source string) (*ast.Node, error) {
+	root := ast.NewNode("Source")
+	stack := []*ast.Node{root}
+
+	for _, token := range tokens {
+		top := stack[len(stack)-1]
+		leaf := ast.NewLeaf(ast.Kind(token.Type.String()), token)
+
+		switch {
+		case token.TypeIs(golex.TypeEof):
+			if len(stack) > 1 {
+				opener := top.Children[0].Token
+				return root, golex.NewErrorOfKind(golex.ErrUnexpectedEOF, fmt.Sprintf("unclosed %s", golex.DisplayName(opener.Type)), opener.Position, []rune(source))
+			}
+		case golex.IsOpeningBracket(token.Type):
+			group := ast.NewNode("Group", leaf)
+			top.Append(group)
+			stack = append(stack, group)
+		case golex.IsClosingBracket(token.Type):
+			if len(stack) == 1 || golex.ClosingPair(top.Children[0].Token.Type) != token.Type {
+				return root, golex.NewErrorOfKind(golex.ErrUnexpectedToken, fmt.Sprintf("unmatched %s", golex.DisplayName(token.Type)), token.Position, []rune(source))
+			}
+
+			top.Append(leaf)
+			stack = stack[:len(stack)-1]
+
+			// Extend the spans of the enclosing groups to the closer
+			for _, node := range stack {
+				node.Span = node.Span.Union(leaf.Span)
+			}
+		default:
+			top.Append(leaf)
+		}
+	}
+
+	return root, nil
+}
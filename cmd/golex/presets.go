@@ -0,0 +1,48 @@
+package main
+
+import (
+	"slices"
+
+	"github.com/cornejong/golex"
+)
+
+// presets holds the named lexer configurations selectable with -preset
+var presets = map[string][]golex.LexerOptionFunc{
+	"default": {},
+	"c": {
+		golex.WithKeywords(
+			"auto", "break", "case", "char", "const", "continue", "default", "do", "double",
+			"else", "enum", "extern", "float", "for", "goto", "if", "int", "long", "register",
+			"return", "short", "signed", "sizeof", "static", "struct", "switch", "typedef",
+			"union", "unsigned", "void", "volatile", "while",
+		),
+	},
+	"go": {
+		golex.WithKeywords(
+			"break", "case", "chan", "const", "continue", "default", "defer", "else",
+			"fallthrough", "for", "func", "go", "goto", "if", "import", "interface", "map",
+			"package", "range", "return", "select", "struct", "switch", "type", "var",
+		),
+		golex.WithStringEnclosure(golex.BacktickStringEnclosure),
+	},
+	"shell": {
+		golex.WithoutCommentSyntax(golex.SlashSingleLineCommentSyntax, golex.SlashMultilineCommentSyntax),
+		golex.WithCommentSyntax(golex.HashtagSingleLineCommentSyntax),
+		golex.WithKeywords("if", "then", "else", "elif", "fi", "for", "while", "do", "done", "case", "esac", "in", "function"),
+	},
+	"json": {
+		golex.WithoutCommentSyntax(golex.SlashSingleLineCommentSyntax, golex.SlashMultilineCommentSyntax),
+		golex.WithoutStringEnclosure("'"),
+		golex.WithKeywords("null"),
+	},
+}
+
+func presetNames() []string {
+	names := []string{}
+	for name := range presets {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/cornejong/golex"
+)
+
+// spec describes a lexer configuration loaded from a JSON file.
+// The spec is applied on top of its preset, or the default preset when none is set.
+// Token types are referred to by their registered names, new token types are declared in types.
+//
+//	{
+//	    "preset": "c",
+//	    "types": ["FatArrow"],
+//	    "keywords": ["let", "fn"],
+//	    "literals": [{"type": "FatArrow", "literal": "=>"}],
+//	    "withoutLiterals": ["Ellipses"],
+//	    "comments": [{"opener": "#"}],
+//	    "strings": [{"type": "BacktickString", "enclosure": "`"}],
+//	    "retainWhitespace": false,
+//	    "ignoreComments": true,
+//	    "symbolStart": "a-zA-Z_",
+//	    "symbolContinue": "a-zA-Z0-9_"
+//	}
+type spec struct {
+	Preset           string        `json:"preset"`
+	Types            []string      `json:"types"`
+	Keywords         []string      `json:"keywords"`
+	Literals         []specLiteral `json:"literals"`
+	WithoutLiterals  []string      `json:"withoutLiterals"`
+	Comments         []specComment `json:"comments"`
+	WithoutComments  []specComment `json:"withoutComments"`
+	Strings          []specString  `json:"strings"`
+	WithoutStrings   []string      `json:"withoutStrings"`
+	RetainWhitespace bool          `json:"retainWhitespace"`
+	IgnoreComments   bool          `json:"ignoreComments"`
+	SymbolStart      string        `json:"symbolStart"`
+	SymbolContinue   string        `json:"symbolContinue"`
+}
+
+type specLiteral struct {
+	Type    string `json:"type"`
+	Literal string `json:"literal"`
+}
+
+type specComment struct {
+	Opener string `json:"opener"`
+	Closer string `json:"closer"`
+}
+
+type specString struct {
+	Type      string `json:"type"`
+	Enclosure string `json:"enclosure"`
+	Escapable bool   `json:"escapable"`
+}
+
+func loadSpec(path string) (spec, error) {
+	s := spec{}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+
+	if err := json.Unmarshal(content, &s); err != nil {
+		return s, fmt.Errorf("invalid spec %s: %w", path, err)
+	}
+
+	return s, nil
+}
+
+func (s spec) Options() ([]golex.LexerOptionFunc, error) {
+	options := []golex.LexerOptionFunc{}
+
+	if len(s.Keywords) > 0 {
+		options = append(options, golex.WithKeywords(s.Keywords...))
+	}
+
+	for _, literal := range s.Literals {
+		if literal.Type == "" || literal.Literal == "" {
+			return nil, fmt.Errorf("literal tokens require a type and a literal")
+		}
+
+		t, err := s.tokenType(literal.Type)
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, golex.WithLiteralTokens(golex.LiteralToken{Type: t, Literal: literal.Literal}))
+	}
+
+	if len(s.WithoutLiterals) > 0 {
+		types := []golex.TokenType{}
+		for _, name := range s.WithoutLiterals {
+			t, err := s.tokenType(name)
+			if err != nil {
+				return nil, err
+			}
+
+			types = append(types, t)
+		}
+
+		options = append(options, golex.WithoutLiteralTokens(types...))
+	}
+
+	for _, comment := range s.WithoutComments {
+		options = append(options, golex.WithoutCommentSyntax(golex.CommentSyntax{Opener: comment.Opener, Closer: comment.Closer}))
+	}
+
+	for _, comment := range s.Comments {
+		if comment.Opener == "" {
+			return nil, fmt.Errorf("comment syntaxes require an opener")
+		}
+
+		options = append(options, golex.WithCommentSyntax(golex.CommentSyntax{Opener: comment.Opener, Closer: comment.Closer}))
+	}
+
+	if len(s.WithoutStrings) > 0 {
+		options = append(options, golex.WithoutStringEnclosure(s.WithoutStrings...))
+	}
+
+	for _, str := range s.Strings {
+		if str.Enclosure == "" {
+			return nil, fmt.Errorf("string enclosures require an enclosure")
+		}
+
+		var t golex.TokenType = golex.TypeString
+		if str.Type != "" {
+			var err error
+			if t, err = s.tokenType(str.Type); err != nil {
+				return nil, err
+			}
+		}
+
+		options = append(options, golex.WithStringEnclosure(golex.StringEnclosure{Type: t, Enclosure: str.Enclosure, Escapable: str.Escapable}))
+	}
+
+	if s.RetainWhitespace {
+		options = append(options, golex.RetainWhitespace())
+	}
+
+	if s.IgnoreComments {
+		options = append(options, golex.IgnoreComments())
+	}
+
+	if s.SymbolStart != "" || s.SymbolContinue != "" {
+		option, err := symbolCharacterMap(s.SymbolStart, s.SymbolContinue)
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, option)
+	}
+
+	return options, nil
+}
+
+// tokenType returns the registered token type with the name, or a new type when the spec declares it
+func (s spec) tokenType(name string) (golex.TokenType, error) {
+	if t, ok := golex.LookupTokenType(name); ok {
+		return t, nil
+	}
+
+	if slices.Contains(s.Types, name) {
+		return golex.CustomType(name), nil
+	}
+
+	return nil, fmt.Errorf("%w %q, declare new token types in the types of the spec", golex.ErrUnknownTokenType, name)
+}
+
+// symbolCharacterMap wraps golex.SymbolCharacterMap which panics on malformed patterns
+func symbolCharacterMap(start string, cont string) (option golex.LexerOptionFunc, err error) {
+	if start == "" {
+		start = "a-zA-Z_"
+	}
+
+	if cont == "" {
+		cont = "a-zA-Z0-9_"
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid symbol character map: %v", r)
+		}
+	}()
+
+	return golex.SymbolCharacterMap(start, cont), nil
+}
+
+// buildLexer creates the lexer for the preset, extended by the spec file when provided
+func buildLexer(preset string, specFile string) (*golex.Lexer, error) {
+	options, err := lexerOptions(preset, specFile)
+	if err != nil {
+		return nil, err
+	}
+
+	lexer := golex.NewLexer(options...)
+	if err := lexer.Err(); err != nil {
+		return nil, err
+	}
+
+	return lexer, nil
+}
+
+// lexerOptions returns the options of the preset, followed by the options of the spec file when provided
+func lexerOptions(preset string, specFile string) ([]golex.LexerOptionFunc, error) {
+	s := spec{}
+	if specFile != "" {
+		var err error
+		if s, err = loadSpec(specFile); err != nil {
+			return nil, err
+		}
+
+		if s.Preset != "" {
+			preset = s.Preset
+		}
+	}
+
+	options, ok := presets[preset]
+	if !ok {
+		return nil, fmt.Errorf("unknown preset %q", preset)
+	}
+
+	specOptions, err := s.Options()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(slices.Clone(options), specOptions...), nil
+}
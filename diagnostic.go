@@ -0,0 +1,361 @@
+package golex
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Severity represents the severity of a diagnostic
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityInfo
+	SeverityHint
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "info"
+	case SeverityHint:
+		return "hint"
+	default:
+		return "error"
+	}
+}
+
+// Label marks a span of the source with an optional message.
+// Primary labels point at the cause of the diagnostic, secondary labels add context.
+type Label struct {
+	Start   Position
+	End     Position
+	Message string
+	Primary bool
+}
+
+// PrimaryLabel creates a primary label for the span from start up to end
+func PrimaryLabel(start Position, end Position, message string) Label {
+	return Label{Start: start, End: end, Message: message, Primary: true}
+}
+
+// SecondaryLabel creates a secondary label for the span from start up to end
+func SecondaryLabel(start Position, end Position, message string) Label {
+	return Label{Start: start, End: end, Message: message}
+}
+
+// Diagnostic is a message about the source with labelled spans, notes and help text
+type Diagnostic struct {
+	Severity Severity
+	Code     string
+	Message  string
+	Labels   []Label
+	Notes    []string
+	Help     []string
+
+	// Source is the content the labels point into
+	Source []rune
+	// Lines is the line table of the source, it is created from the source when not set
+	Lines *LineTable
+}
+
+// String renders the diagnostic as plain text
+func (d Diagnostic) String() string {
+	var sb strings.Builder
+	DiagnosticRenderer{}.Render(&sb, d)
+	return sb.String()
+}
+
+// ###################################################
+// #              Renderer
+// ###################################################
+
+const (
+	ansiReset  = "\033[0m"
+	ansiBold   = "\033[1m"
+	ansiRed    = "\033[31m"
+	ansiYellow = "\033[33m"
+	ansiBlue   = "\033[34m"
+	ansiCyan   = "\033[36m"
+	ansiGreen  = "\033[32m"
+)
+
+// maxLabelLines is the number of lines rendered for a multi-line label before eliding
+const maxLabelLines = 4
+
+// DiagnosticRenderer renders diagnostics in the style of rustc with
+// line-number gutters and underlined spans, either as plain text or with ANSI colours.
+// Underlines are aligned using the display width of the characters. When TabWidth
+// is set tabs are expanded to spaces, otherwise they are copied into the underline.
+type DiagnosticRenderer struct {
+	Color    bool
+	TabWidth int
+}
+
+// Render writes the rendered diagnostic to w
+func (r DiagnosticRenderer) Render(w io.Writer, d Diagnostic) error {
+	var sb strings.Builder
+
+	r.writeHeader(&sb, d)
+
+	labels := slices.Clone(d.Labels)
+	slices.SortStableFunc(labels, func(a, b Label) int {
+		return a.Start.Cursor - b.Start.Cursor
+	})
+
+	gutterWidth := labelsGutterWidth(labels)
+	gutter := strings.Repeat(" ", gutterWidth)
+
+	if len(labels) > 0 {
+		primary := labels[0]
+		for _, label := range labels {
+			if label.Primary {
+				primary = label
+				break
+			}
+		}
+
+		sb.WriteString(fmt.Sprintf("%s%s %s\n", gutter, r.paint(ansiBlue, "-->"), primary.Start.Location()))
+	}
+
+	if len(labels) > 0 && len(d.Source) > 0 {
+		sb.WriteString(fmt.Sprintf("%s %s\n", gutter, r.paint(ansiBlue, "|")))
+		r.writeSnippet(&sb, d, labels, gutterWidth)
+	}
+
+	if len(d.Notes) > 0 || len(d.Help) > 0 {
+		if len(labels) > 0 && len(d.Source) > 0 {
+			sb.WriteString(fmt.Sprintf("%s %s\n", gutter, r.paint(ansiBlue, "|")))
+		}
+
+		for _, note := range d.Notes {
+			sb.WriteString(fmt.Sprintf("%s %s %s %s\n", gutter, r.paint(ansiBlue, "="), r.paint(ansiBold, "note:"), note))
+		}
+
+		for _, help := range d.Help {
+			sb.WriteString(fmt.Sprintf("%s %s %s %s\n", gutter, r.paint(ansiBlue, "="), r.paint(ansiBold, "help:"), help))
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func (r DiagnosticRenderer) writeHeader(sb *strings.Builder, d Diagnostic) {
+	title := d.Severity.String()
+	if d.Code != "" {
+		title += "[" + d.Code + "]"
+	}
+
+	sb.WriteString(r.paint(ansiBold+r.severityColor(d.Severity), title))
+	sb.WriteString(r.paint(ansiBold, ": "+d.Message))
+	sb.WriteString("\n")
+}
+
+// labelsGutterWidth returns the width of the widest line number of the labels
+func labelsGutterWidth(labels []Label) int {
+	width := 1
+	for _, label := range labels {
+		width = max(width, len(strconv.Itoa(max(label.Start.Row, label.End.Row))))
+	}
+
+	return width
+}
+
+// writeSnippet writes the source lines covered by the labels with their underlines
+func (r DiagnosticRenderer) writeSnippet(sb *strings.Builder, d Diagnostic, labels []Label, gutterWidth int) {
+	table := d.Lines
+	if table == nil {
+		table = NewLineTable(d.Source)
+	}
+
+	lines := map[int]sourceLine{}
+	rows := []int{}
+
+	for _, label := range labels {
+		for _, row := range labelRows(label) {
+			if _, ok := lines[row]; ok {
+				continue
+			}
+
+			lines[row] = sourceLine{start: table.LineStart(row), text: table.lineRunes(row)}
+			rows = append(rows, row)
+		}
+	}
+
+	slices.Sort(rows)
+
+	for i, row := range rows {
+		if i > 0 && row > rows[i-1]+1 {
+			sb.WriteString(r.paint(ansiBlue, "...") + "\n")
+		}
+
+		line := lines[row]
+		sb.WriteString(fmt.Sprintf("%s %s %s\n", r.paint(ansiBlue, fmt.Sprintf("%*d", gutterWidth, row)), r.paint(ansiBlue, "|"), r.displayLine(line.text)))
+
+		for _, label := range labels {
+			start, end, ok := label.columnsOn(row, line)
+			if !ok {
+				continue
+			}
+
+			marker, color := "-", ansiBlue
+			if label.Primary {
+				marker, color = "^", r.severityColor(d.Severity)
+			}
+
+			underline := r.indentation(line.text[:start]) + strings.Repeat(marker, max(r.spanWidth(line.text, start, end), 1))
+
+			message := ""
+			if label.Message != "" && row == label.lastRow() {
+				message = " " + label.Message
+			}
+
+			sb.WriteString(fmt.Sprintf("%s %s %s\n", strings.Repeat(" ", gutterWidth), r.paint(ansiBlue, "|"), r.paint(ansiBold+color, underline+message)))
+		}
+	}
+}
+
+func (r DiagnosticRenderer) severityColor(s Severity) string {
+	switch s {
+	case SeverityWarning:
+		return ansiYellow
+	case SeverityInfo:
+		return ansiGreen
+	case SeverityHint:
+		return ansiCyan
+	default:
+		return ansiRed
+	}
+}
+
+func (r DiagnosticRenderer) paint(color string, text string) string {
+	if !r.Color || text == "" {
+		return text
+	}
+
+	return color + text + ansiReset
+}
+
+// sourceLine is a single line of the source without its line terminator
+type sourceLine struct {
+	start int
+	text  []rune
+}
+
+// labelRows returns the rows of the label, eliding the middle of long spans
+func labelRows(label Label) []int {
+	last := label.lastRow()
+	if last-label.Start.Row < maxLabelLines {
+		rows := []int{}
+		for row := label.Start.Row; row <= last; row++ {
+			rows = append(rows, row)
+		}
+
+		return rows
+	}
+
+	return []int{label.Start.Row, label.Start.Row + 1, last - 1, last}
+}
+
+func (l Label) lastRow() int {
+	if l.End.Row < l.Start.Row || l.End.Cursor <= l.Start.Cursor {
+		return l.Start.Row
+	}
+
+	// A span ending at the very start of a line doesn't cover that line
+	if l.End.Col == 1 && l.End.Row > l.Start.Row {
+		return l.End.Row - 1
+	}
+
+	return l.End.Row
+}
+
+// columnsOn returns the rune columns of the line covered by the label
+func (l Label) columnsOn(row int, line sourceLine) (int, int, bool) {
+	if row < l.Start.Row || row > l.lastRow() {
+		return 0, 0, false
+	}
+
+	start := 0
+	if row == l.Start.Row {
+		start = l.Start.Cursor - line.start
+	}
+
+	end := len(line.text)
+	if row == l.lastRow() {
+		if l.End.Cursor > l.Start.Cursor {
+			end = min(l.End.Cursor-line.start, len(line.text))
+		} else {
+			end = start + 1
+		}
+	}
+
+	start = min(max(start, 0), len(line.text))
+	return start, max(end, start), true
+}
+
+// displayLine returns the line as it is rendered, with expanded tabs when a tab width is set
+func (r DiagnosticRenderer) displayLine(text []rune) string {
+	if r.TabWidth <= 0 {
+		return string(text)
+	}
+
+	var sb strings.Builder
+	col := 1
+	for _, char := range text {
+		next := NextVisualColumn(col, char, r.TabWidth)
+		if char == '\t' {
+			sb.WriteString(strings.Repeat(" ", next-col))
+		} else {
+			sb.WriteRune(char)
+		}
+
+		col = next
+	}
+
+	return sb.String()
+}
+
+// indentation returns the whitespace that aligns with the displayed prefix of a line
+func (r DiagnosticRenderer) indentation(prefix []rune) string {
+	if r.TabWidth > 0 {
+		return strings.Repeat(" ", runesWidth(prefix, 1, r.TabWidth))
+	}
+
+	var sb strings.Builder
+	for _, char := range prefix {
+		if char == '\t' {
+			sb.WriteRune('\t')
+		} else {
+			sb.WriteString(strings.Repeat(" ", RuneWidth(char)))
+		}
+	}
+
+	return sb.String()
+}
+
+// spanWidth returns the displayed width of the characters between start and end of the line
+func (r DiagnosticRenderer) spanWidth(text []rune, start int, end int) int {
+	if r.TabWidth > 0 {
+		col := runesWidth(text[:start], 1, r.TabWidth) + 1
+		return runesWidth(text[start:end], col, r.TabWidth)
+	}
+
+	width := 0
+	for _, char := range text[start:end] {
+		if char == '\t' {
+			width += 1
+		} else {
+			width += RuneWidth(char)
+		}
+	}
+
+	return width
+}
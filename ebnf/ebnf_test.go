@@ -0,0 +1,193 @@
+package ebnf
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cornejong/golex"
+	"github.com/cornejong/golex/ast"
+	"github.com/cornejong/golex/internal/golextest"
+	"github.com/cornejong/golex/parse"
+)
+
+const calculatorGrammar = `
+// A small calculator language
+Program    = { Statement } .
+Statement  = ( Assignment | Expression ) ";" .
+Assignment = "let" Symbol "=" Expression .
+Expression = Term { ( "+" | "-" ) Term } .
+Term       = Factor { ( "*" | "/" ) Factor } .
+Factor     = Integer | Call | Symbol | "(" Expression ")" .
+Call       = "call" "(" [ Arguments ] ")" .
+Arguments  = Expression { "," Expression } .
+`
+
+func TestGrammarParse(t *testing.T) {
+	fmt.Println("TestGrammarParse...")
+
+	grammar, err := Parse("calc.ebnf", calculatorGrammar)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	differ := &golex.Differ{}
+	differ.Compare([]string{"Program", "Statement", "Assignment", "Expression", "Term", "Factor", "Call", "Arguments"}, grammar.Names)
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+
+	call := grammar.Productions["Call"]
+	sequence, ok := call.Expr.(Sequence)
+	if !ok || len(sequence) != 4 {
+		t.Fatalf("Expected Call to be a sequence of 4 terms but got %#v", call.Expr)
+	}
+
+	if option, ok := sequence[2].(*Option); !ok || option.Body.(*Name).String != "Arguments" {
+		t.Errorf("Expected an optional Arguments but got %#v", sequence[2])
+	}
+
+	if location := call.Name.Position.Location(); location != "calc.ebnf:9:1" {
+		t.Errorf("Expected Call at calc.ebnf:9:1 but got %s", location)
+	}
+
+	for source, message := range map[string]string{
+		`A = "a"`:             `1:8: expected '.' but found the end of the grammar`,
+		`A = "a" | .`:         `1:11: expected a term but found '.'`,
+		`A = ( "a" .`:         `1:11: expected ')' but found '.'`,
+		`A = "a" … "z" .`:     `1:9: character ranges are not supported, use a token type instead`,
+		`A = "a" . A = "b" .`: `1:11: production A is already declared at 1:1`,
+	} {
+		_, err := Parse("", source)
+
+		var lexErr *golex.Error
+		if !errors.As(err, &lexErr) || lexErr.Position.Location()+": "+lexErr.Message != message {
+			t.Errorf("Expected %q for %q but got %v", message, source, err)
+		}
+	}
+}
+
+func TestGrammarVerify(t *testing.T) {
+	fmt.Println("TestGrammarVerify...")
+
+	grammar, err := Parse("", calculatorGrammar)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := grammar.Verify("Program"); err != nil {
+		t.Errorf("Expected the calculator grammar to be valid but got %v", err)
+	}
+
+	if err := grammar.Verify("Module"); !errors.Is(err, ErrUndefined) {
+		t.Errorf("Expected an undefined start production but got %v", err)
+	}
+
+	grammar, _ = Parse("", `
+List  = Items .
+Items = [ Items "," ] Item .
+Item  = Value | Other .
+Value = Integer .
+`)
+
+	err = grammar.Verify("List")
+	if !errors.Is(err, ErrUndefined) || !errors.Is(err, ErrLeftRecursion) {
+		t.Fatalf("Expected undefined and left recursion errors but got %v", err)
+	}
+
+	differ := &golex.Differ{}
+	differ.Compare("4:17: Other is not a production or a token type\n3:11: left recursion Items → Items", err.Error())
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+
+	// Left recursion through nullable productions and across productions
+	grammar, _ = Parse("", `
+A = [ "x" ] B "a" .
+B = Empty C .
+C = { "y" } A | "c" .
+Empty = .
+`)
+
+	if err := grammar.Verify("A"); err == nil || err.Error() != "4:13: left recursion A → B → C → A" {
+		t.Errorf("Expected left recursion through A, B and C but got %v", err)
+	}
+}
+
+func TestGrammarParseTokens(t *testing.T) {
+	fmt.Println("TestGrammarParseTokens...")
+
+	grammar, err := Parse("", calculatorGrammar)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree, err := grammar.Parse("Program", golextest.Tokenize(t, "let x = 1 + 2 * y; call(x, (3));"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `(Program` +
+		` (Statement (Assignment (Symbol "let") (Symbol "x") (Assign "=")` +
+		` (Expression (Term (Factor (Integer "1"))) (Plus "+") (Term (Factor (Integer "2")) (Multiply "*") (Factor (Symbol "y"))))) (Semicolon ";"))` +
+		` (Statement (Expression (Term (Factor (Call (Symbol "call") (OpenParenthesis "(")` +
+		` (Arguments (Expression (Term (Factor (Symbol "x")))) (Comma ",") (Expression (Term (Factor (OpenParenthesis "(") (Expression (Term (Factor (Integer "3")))) (CloseParenthesis ")")))))` +
+		` (CloseParenthesis ")"))))) (Semicolon ";")))`
+
+	differ := &golex.Differ{}
+	differ.Compare(expected, ast.SExpr(tree))
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+
+	if statement := tree.Children[1]; statement.Span.Start.Col != 20 || statement.Span.End.Col != 33 {
+		t.Errorf("Expected the second statement to span 20-33 but got %s", statement.Span)
+	}
+
+	_, err = grammar.Parse("Program", golextest.Tokenize(t, "let x = 1 +;"))
+
+	var parseErr *parse.Error
+	if !errors.As(err, &parseErr) || !errors.Is(err, golex.ErrUnexpectedToken) {
+		t.Fatalf("Expected a parse error but got %v", err)
+	}
+
+	differ.Compare("1:12: expected integer, 'call', symbol or '(' but found ';'", err.Error())
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+
+	if _, err := grammar.Parse("Program", golextest.Tokenize(t, "call(1")); !errors.Is(err, golex.ErrUnexpectedEOF) {
+		t.Errorf("Expected an unexpected EOF error but got %v", err)
+	}
+
+	// Grammars are verified before parsing
+	if _, err := grammar.Parse("Module", golextest.Tokenize(t, "1;")); !errors.Is(err, ErrUndefined) {
+		t.Errorf("Expected an undefined production error but got %v", err)
+	}
+
+	if _, err := grammar.Parser("Module"); !errors.Is(err, golex.ErrUndefined) {
+		t.Errorf("Expected an undefined production error but got %v", err)
+	}
+
+	// A parser is verified once and parses any number of sources
+	parser, err := grammar.Parser("Program")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, source := range []string{"1;", "let x = 1 + 2 * y; call(x, (3));", "x;"} {
+		tree, err := parser.Parse(golextest.Tokenize(t, source))
+		expected, _ := grammar.Parse("Program", golextest.Tokenize(t, source))
+		if err != nil || ast.SExpr(tree) != ast.SExpr(expected) {
+			t.Errorf("Expected the parser to parse %q like Grammar.Parse but got %v", source, err)
+		}
+	}
+
+	if _, err := parser.Parse(golextest.Tokenize(t, "let x = 1 +;")); !errors.Is(err, golex.ErrUnexpectedToken) {
+		t.Errorf("Expected the parser to return syntax errors but got %v", err)
+	}
+}
@@ -0,0 +1,278 @@
+// Package ebnf parses inputs against a grammar written in EBNF.
+//
+// Grammars use the notation of golang.org/x/exp/ebnf:
+//
+//	Production  = name "=" [ Expression ] "." .
+//	Expression  = Alternative { "|" Alternative } .
+//	Alternative = Term { Term } .
+//	Term        = name | token | Group | Option | Repetition .
+//	Group       = "(" Expression ")" .
+//	Option      = "[" Expression "]" .
+//	Repetition  = "{" Expression "}" .
+//
+// A token is a string literal which matches a golex token with that literal.
+// A name which is not defined as a production refers to a registered golex
+// token type, like Symbol or Integer. The grammar describes tokens rather than
+// characters, so character ranges are not supported.
+package ebnf
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/cornejong/golex"
+)
+
+// Grammar is a set of productions
+type Grammar struct {
+	Productions map[string]*Production
+	// Names holds the names of the productions in the order they were declared
+	Names []string
+}
+
+// Production is a named expression, the expression is nil for empty productions
+type Production struct {
+	Name *Name
+	Expr Expression
+}
+
+// Expression is a node of the expression of a production
+type Expression interface {
+	Pos() golex.Position
+}
+
+type (
+	// Alternative is a list of expressions of which one has to match: x | y | z
+	Alternative []Expression
+
+	// Sequence is a list of expressions which have to match in order: x y z
+	Sequence []Expression
+
+	// Name refers to a production or a token type
+	Name struct {
+		Position golex.Position
+		String   string
+	}
+
+	// Token matches a token with the literal
+	Token struct {
+		Position golex.Position
+		String   string
+	}
+
+	// Group is a parenthesised expression: (body)
+	Group struct {
+		Position golex.Position
+		Body     Expression
+	}
+
+	// Option is an optional expression: [body]
+	Option struct {
+		Position golex.Position
+		Body     Expression
+	}
+
+	// Repetition is an expression which matches zero or more times: {body}
+	Repetition struct {
+		Position golex.Position
+		Body     Expression
+	}
+)
+
+func (x Alternative) Pos() golex.Position { return x[0].Pos() }
+func (x Sequence) Pos() golex.Position    { return x[0].Pos() }
+func (x *Name) Pos() golex.Position       { return x.Position }
+func (x *Token) Pos() golex.Position      { return x.Position }
+func (x *Group) Pos() golex.Position      { return x.Position }
+func (x *Option) Pos() golex.Position     { return x.Position }
+func (x *Repetition) Pos() golex.Position { return x.Position }
+
+// ###################################################
+// #              Grammar Parser
+// ###################################################
+
+// Parse parses the grammar source. The filename is included in the positions of the grammar and may be empty.
+func Parse(filename string, source string) (*Grammar, error) {
+	lexer := golex.NewLexer(
+		golex.WithStringEnclosure(golex.BacktickStringEnclosure),
+		golex.WithLiteralTokens(golex.LiteralToken{Type: golex.TypeEllipses, Literal: "…"}),
+		golex.IgnoreTokens(golex.TypeComment),
+	)
+
+	tokens := []golex.Token{}
+	for token, err := range lexer.IterateNamed(filename, source) {
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	p := &grammarParser{lexer: lexer, tokens: golex.NewTokenCollection(tokens)}
+	grammar := &Grammar{Productions: map[string]*Production{}}
+
+	for !p.tokens.Peek(1).TypeIs(golex.TypeEof) {
+		production, err := p.production()
+		if err != nil {
+			return nil, err
+		}
+
+		name := production.Name.String
+		if previous, ok := grammar.Productions[name]; ok {
+			return nil, p.lexer.ErrorAt(golex.ErrUnexpectedToken, fmt.Sprintf("production %s is already declared at %s", name, previous.Name.Position.Location()), production.Name.Position)
+		}
+
+		grammar.Productions[name] = production
+		grammar.Names = append(grammar.Names, name)
+	}
+
+	return grammar, nil
+}
+
+type grammarParser struct {
+	lexer  *golex.Lexer
+	tokens golex.TokenCollection
+}
+
+func (p *grammarParser) unexpected(expected string) error {
+	token := p.tokens.Peek(1)
+	if token.TypeIs(golex.TypeEof) {
+		return p.lexer.ErrorAt(golex.ErrUnexpectedEOF, fmt.Sprintf("expected %s but found the end of the grammar", expected), token.Position)
+	}
+
+	return p.lexer.ErrorAt(golex.ErrUnexpectedToken, fmt.Sprintf("expected %s but found '%s'", expected, token.Literal), token.Position)
+}
+
+func (p *grammarParser) expect(tokenType golex.TokenType) (golex.Token, error) {
+	token, ok := p.tokens.Accept(tokenType)
+	if !ok {
+		return token, p.unexpected(golex.DisplayName(tokenType))
+	}
+
+	return token, nil
+}
+
+func (p *grammarParser) production() (*Production, error) {
+	name, ok := p.tokens.Accept(golex.TypeSymbol)
+	if !ok {
+		return nil, p.unexpected("a production name")
+	}
+
+	if _, err := p.expect(golex.TypeAssign); err != nil {
+		return nil, err
+	}
+
+	production := &Production{Name: &Name{Position: name.Position, String: name.Literal}}
+	if !p.tokens.Peek(1).TypeIs(golex.TypeDot) {
+		expr, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+
+		production.Expr = expr
+	}
+
+	if _, err := p.expect(golex.TypeDot); err != nil {
+		return nil, err
+	}
+
+	return production, nil
+}
+
+func (p *grammarParser) expression() (Expression, error) {
+	alternatives := Alternative{}
+	for {
+		sequence, err := p.sequence()
+		if err != nil {
+			return nil, err
+		}
+
+		alternatives = append(alternatives, sequence)
+		if _, ok := p.tokens.Accept(golex.TypePipe); !ok {
+			break
+		}
+	}
+
+	if len(alternatives) == 1 {
+		return alternatives[0], nil
+	}
+
+	return alternatives, nil
+}
+
+func (p *grammarParser) sequence() (Expression, error) {
+	sequence := Sequence{}
+	for {
+		term, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+
+		if term == nil {
+			break
+		}
+
+		sequence = append(sequence, term)
+	}
+
+	switch len(sequence) {
+	case 0:
+		return nil, p.unexpected("a term")
+	case 1:
+		return sequence[0], nil
+	}
+
+	return sequence, nil
+}
+
+// term parses a term, it returns nil when there is no term at the cursor
+func (p *grammarParser) term() (Expression, error) {
+	token := p.tokens.Peek(1)
+
+	switch token.Type {
+	case golex.TypeSymbol:
+		p.tokens.Advance()
+		return &Name{Position: token.Position, String: token.Literal}, nil
+
+	case golex.TypeDoubleQuoteString, golex.TypeBacktickString:
+		p.tokens.Advance()
+
+		literal, err := strconv.Unquote(token.Literal)
+		if err != nil {
+			return nil, p.lexer.ErrorAt(golex.ErrUnexpectedToken, fmt.Sprintf("malformed token %s", token.Literal), token.Position)
+		}
+
+		if literal == "" {
+			return nil, p.lexer.ErrorAt(golex.ErrUnexpectedToken, "empty token", token.Position)
+		}
+
+		if p.tokens.Peek(1).TypeIs(golex.TypeEllipses) {
+			return nil, p.lexer.ErrorAt(golex.ErrUnexpectedToken, "character ranges are not supported, use a token type instead", p.tokens.Peek(1).Position)
+		}
+
+		return &Token{Position: token.Position, String: literal}, nil
+
+	case golex.TypeOpenParen, golex.TypeOpenSquare, golex.TypeOpenCurly:
+		p.tokens.Advance()
+
+		body, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := p.expect(golex.ClosingPair(token.Type)); err != nil {
+			return nil, err
+		}
+
+		switch token.Type {
+		case golex.TypeOpenParen:
+			return &Group{Position: token.Position, Body: body}, nil
+		case golex.TypeOpenSquare:
+			return &Option{Position: token.Position, Body: body}, nil
+		}
+
+		return &Repetition{Position: token.Position, Body: body}, nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,132 @@
+package ebnf
+
+import (
+	"github.com/cornejong/golex"
+	"github.com/cornejong/golex/ast"
+	"github.com/cornejong/golex/parse"
+)
+
+// Parser parses tokens as a production of a grammar. It is verified and compiled
+// once by Grammar.Parser and can be reused for any number of parses, also concurrently.
+// Changes made to the grammar afterwards are not seen by the parser.
+type Parser struct {
+	start parse.Parser[*ast.Node]
+}
+
+// Parser verifies the grammar and compiles the start production to a parser.
+func (g *Grammar) Parser(start string) (*Parser, error) {
+	if err := g.Verify(start); err != nil {
+		return nil, err
+	}
+
+	c := &compiler{grammar: g, rules: map[string]parse.Parser[*ast.Node]{}}
+	return &Parser{start: c.production(start)}, nil
+}
+
+// Parse parses the tokens as the start production, all the tokens have to be consumed.
+//
+// The tree has a node for every matched production, with the name of the
+// production as its kind. Matched tokens are leaves with the token type as
+// their kind. Groups, options and repetitions do not create nodes of their own,
+// their matches are added to the node of the production.
+//
+// Alternatives are tried in order and the first that matches is used.
+// A syntax error is returned as a *parse.Error.
+func (p *Parser) Parse(tokens golex.Tokens) (*ast.Node, error) {
+	return parse.Parse(p.start, tokens)
+}
+
+// Parse verifies the grammar and parses the tokens as the start production, see Parser.Parse.
+// The grammar is verified and compiled on every call, use Grammar.Parser to parse more than once.
+func (g *Grammar) Parse(start string, tokens golex.Tokens) (*ast.Node, error) {
+	p, err := g.Parser(start)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.Parse(tokens)
+}
+
+// compiler converts the productions of a grammar to parsers
+type compiler struct {
+	grammar *Grammar
+	rules   map[string]parse.Parser[*ast.Node]
+}
+
+func (c *compiler) production(name string) parse.Parser[*ast.Node] {
+	if rule, ok := c.rules[name]; ok {
+		return rule
+	}
+
+	// The rule is registered before its body is compiled, so recursive references find it
+	var body parse.Parser[[]*ast.Node]
+	rule := func(s *parse.State) (*ast.Node, bool) {
+		children, ok := body(s)
+		if !ok {
+			return nil, false
+		}
+
+		return ast.NewNode(ast.Kind(name), children...), true
+	}
+
+	c.rules[name] = rule
+	body = c.expression(c.grammar.Productions[name].Expr)
+
+	return rule
+}
+
+func (c *compiler) expression(expr Expression) parse.Parser[[]*ast.Node] {
+	switch x := expr.(type) {
+	case Alternative:
+		alternatives := make([]parse.Parser[[]*ast.Node], len(x))
+		for i, e := range x {
+			alternatives[i] = c.expression(e)
+		}
+
+		return parse.Alt(alternatives...)
+
+	case Sequence:
+		sequence := make([]parse.Parser[[]*ast.Node], len(x))
+		for i, e := range x {
+			sequence[i] = c.expression(e)
+		}
+
+		return parse.Map(parse.Seq(sequence...), flatten)
+
+	case *Name:
+		if _, ok := c.grammar.Productions[x.String]; ok {
+			return parse.Map(c.production(x.String), func(node *ast.Node) []*ast.Node { return []*ast.Node{node} })
+		}
+
+		tokenType, _ := golex.LookupTokenType(x.String)
+		return parse.Map(parse.Type(tokenType), leaf)
+
+	case *Token:
+		return parse.Map(parse.Literal(x.String), leaf)
+
+	case *Group:
+		return c.expression(x.Body)
+
+	case *Option:
+		return parse.Optional(c.expression(x.Body))
+
+	case *Repetition:
+		return parse.Map(parse.Many(c.expression(x.Body)), flatten)
+	}
+
+	// Empty productions match without consuming a token
+	return func(s *parse.State) ([]*ast.Node, bool) { return nil, true }
+}
+
+func leaf(token golex.Token) []*ast.Node {
+	return []*ast.Node{ast.NewLeaf(ast.Kind(token.Type.String()), token)}
+}
+
+func flatten(lists [][]*ast.Node) []*ast.Node {
+	nodes := []*ast.Node{}
+	for _, list := range lists {
+		nodes = append(nodes, list...)
+	}
+
+	return nodes
+}
@@ -0,0 +1,211 @@
+package ebnf
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cornejong/golex"
+)
+
+var (
+	// ErrUndefined is golex.ErrUndefined, the kind of errors for names which are neither a production nor a registered token type
+	ErrUndefined = golex.ErrUndefined
+	// ErrLeftRecursion is the kind of errors for productions which can derive themselves without consuming a token
+	ErrLeftRecursion = errors.New("left recursion")
+)
+
+// Error is a problem with a grammar
+type Error struct {
+	Kind     error
+	Message  string
+	Position golex.Position
+}
+
+func (e *Error) Error() string {
+	if e.Position.Row == 0 {
+		return e.Message
+	}
+
+	return fmt.Sprintf("%s: %s", e.Position.Location(), e.Message)
+}
+
+// Unwrap returns the kind of the error so it can be matched using errors.Is
+func (e *Error) Unwrap() error { return e.Kind }
+
+// Verify checks that the start production and every name used by the grammar
+// is defined, and that no production is left recursive. Left recursion would
+// make the parser loop forever, so the grammar has to be verified before use.
+// All the problems are returned joined in a single error.
+func (g *Grammar) Verify(start string) error {
+	errs := []error{}
+
+	if _, ok := g.Productions[start]; !ok {
+		errs = append(errs, &Error{Kind: ErrUndefined, Message: fmt.Sprintf("start production %s is not defined", start)})
+	}
+
+	for _, name := range g.Names {
+		g.walk(g.Productions[name].Expr, func(expr Expression) {
+			if x, ok := expr.(*Name); ok && !g.defined(x.String) {
+				errs = append(errs, &Error{Kind: ErrUndefined, Message: fmt.Sprintf("%s is not a production or a token type", x.String), Position: x.Position})
+			}
+		})
+	}
+
+	errs = append(errs, g.leftRecursion()...)
+	return errors.Join(errs...)
+}
+
+// defined reports whether the name is a production or a registered token type
+func (g *Grammar) defined(name string) bool {
+	if _, ok := g.Productions[name]; ok {
+		return true
+	}
+
+	_, ok := golex.LookupTokenType(name)
+	return ok
+}
+
+// walk calls the function for the expression and all the expressions it contains
+func (g *Grammar) walk(expr Expression, f func(Expression)) {
+	if expr == nil {
+		return
+	}
+
+	f(expr)
+
+	switch x := expr.(type) {
+	case Alternative:
+		for _, e := range x {
+			g.walk(e, f)
+		}
+	case Sequence:
+		for _, e := range x {
+			g.walk(e, f)
+		}
+	case *Group:
+		g.walk(x.Body, f)
+	case *Option:
+		g.walk(x.Body, f)
+	case *Repetition:
+		g.walk(x.Body, f)
+	}
+}
+
+// ###################################################
+// #              Left Recursion
+// ###################################################
+
+// nullable returns the productions which can match without consuming a token
+func (g *Grammar) nullable() map[string]bool {
+	nullable := map[string]bool{}
+
+	for changed := true; changed; {
+		changed = false
+		for _, name := range g.Names {
+			if !nullable[name] && isNullable(g.Productions[name].Expr, nullable) {
+				nullable[name] = true
+				changed = true
+			}
+		}
+	}
+
+	return nullable
+}
+
+func isNullable(expr Expression, nullable map[string]bool) bool {
+	switch x := expr.(type) {
+	case nil:
+		return true
+	case Alternative:
+		return slices.ContainsFunc(x, func(e Expression) bool { return isNullable(e, nullable) })
+	case Sequence:
+		for _, e := range x {
+			if !isNullable(e, nullable) {
+				return false
+			}
+		}
+
+		return true
+	case *Name:
+		return nullable[x.String]
+	case *Group:
+		return isNullable(x.Body, nullable)
+	case *Option, *Repetition:
+		return true
+	}
+
+	return false
+}
+
+// leftmost appends the references to productions which can be reached by the expression without consuming a token
+func (g *Grammar) leftmost(expr Expression, nullable map[string]bool, names []*Name) []*Name {
+	switch x := expr.(type) {
+	case Alternative:
+		for _, e := range x {
+			names = g.leftmost(e, nullable, names)
+		}
+	case Sequence:
+		for _, e := range x {
+			names = g.leftmost(e, nullable, names)
+			if !isNullable(e, nullable) {
+				break
+			}
+		}
+	case *Name:
+		if _, ok := g.Productions[x.String]; ok {
+			names = append(names, x)
+		}
+	case *Group:
+		names = g.leftmost(x.Body, nullable, names)
+	case *Option:
+		names = g.leftmost(x.Body, nullable, names)
+	case *Repetition:
+		names = g.leftmost(x.Body, nullable, names)
+	}
+
+	return names
+}
+
+// leftRecursion returns an error for every cycle of productions that can be entered without consuming a token
+func (g *Grammar) leftRecursion() []error {
+	nullable := g.nullable()
+	errs := []error{}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := map[string]int{}
+	path := []string{}
+
+	var visit func(name string)
+	visit = func(name string) {
+		state[name] = visiting
+		path = append(path, name)
+
+		for _, next := range g.leftmost(g.Productions[name].Expr, nullable, nil) {
+			switch state[next.String] {
+			case unvisited:
+				visit(next.String)
+			case visiting:
+				cycle := append(slices.Clone(path[slices.Index(path, next.String):]), next.String)
+				errs = append(errs, &Error{Kind: ErrLeftRecursion, Message: fmt.Sprintf("left recursion %s", strings.Join(cycle, " → ")), Position: next.Position})
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[name] = visited
+	}
+
+	for _, name := range g.Names {
+		if state[name] == unvisited {
+			visit(name)
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,161 @@
+package golex
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Pos is a compact representation of a position within a FileSet.
+// It can be resolved to a Position, including the filename, using FileSet.Position.
+type Pos int
+
+// NoPos is the zero value of Pos which is not part of any file
+const NoPos Pos = 0
+
+// IsValid checks if the pos is part of a file
+func (p Pos) IsValid() bool {
+	return p != NoPos
+}
+
+// File is a named source registered in a FileSet
+type File struct {
+	name    string
+	base    int
+	content []rune
+	lines   *LineTable
+
+	encoding Encoding
+	invalid  []int
+}
+
+// Name returns the name of the file
+func (f *File) Name() string { return f.name }
+
+// Base returns the pos of the first character of the file
+func (f *File) Base() int { return f.base }
+
+// Size returns the number of characters in the file
+func (f *File) Size() int { return len(f.content) }
+
+// Content returns the content of the file
+func (f *File) Content() string { return string(f.content) }
+
+// Encoding returns the detected encoding of the file content
+func (f *File) Encoding() Encoding { return f.encoding }
+
+// Pos returns the pos of the cursor offset within the file
+func (f *File) Pos(offset int) Pos {
+	return Pos(f.base + min(max(offset, 0), f.Size()))
+}
+
+// Offset returns the cursor offset of the pos within the file
+func (f *File) Offset(p Pos) int {
+	return min(max(int(p)-f.base, 0), f.Size())
+}
+
+// Position returns the position of the pos within the file
+func (f *File) Position(p Pos) Position {
+	position := f.lines.PositionOf(f.Offset(p))
+	position.Filename = f.name
+
+	return position
+}
+
+// Lines returns the line table of the file
+func (f *File) Lines() *LineTable { return f.lines }
+
+// FileSet registers named sources and assigns each a unique range of Pos values,
+// in the spirit of go/token.FileSet
+type FileSet struct {
+	mutex sync.RWMutex
+	base  int
+	files []*File
+}
+
+func NewFileSet() *FileSet {
+	return &FileSet{base: 1}
+}
+
+// AddFile registers a source with the provided name, recognising all line terminators.
+// The content is decoded like NewState, stripping a byte-order mark and transcoding UTF-16 and UTF-32.
+func (s *FileSet) AddFile(name string, content string) *File {
+	return s.AddFileWith(name, content, NewlinesUnicode)
+}
+
+// AddFileWith registers a source with the provided name, only recognising the provided line terminators.
+// The lexer and FileSet.Position both use the line table of the file.
+func (s *FileSet) AddFileWith(name string, content string, newlines Newlines) *File {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	file := &File{name: name, base: s.base}
+	file.encoding, file.content, file.invalid = decodeSource(content)
+	file.lines = NewLineTableWith(file.content, newlines)
+
+	// The +1 allows a pos directly after the last character, like the EOF token
+	s.base += file.Size() + 1
+	s.files = append(s.files, file)
+
+	return file
+}
+
+// File returns the file containing the pos or nil
+func (s *FileSet) File(p Pos) *File {
+	if !p.IsValid() {
+		return nil
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	i, found := slices.BinarySearchFunc(s.files, int(p), func(f *File, target int) int {
+		if target < f.base {
+			return 1
+		}
+
+		if target > f.base+f.Size() {
+			return -1
+		}
+
+		return 0
+	})
+
+	if !found {
+		return nil
+	}
+
+	return s.files[i]
+}
+
+// Files returns the registered files in the order they were added
+func (s *FileSet) Files() []*File {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return slices.Clone(s.files)
+}
+
+// Position resolves the pos to a position including the filename
+func (s *FileSet) Position(p Pos) Position {
+	file := s.File(p)
+	if file == nil {
+		return Position{}
+	}
+
+	return file.Position(p)
+}
+
+// Location resolves the pos to a file:line:col string
+func (s *FileSet) Location(p Pos) string {
+	return s.Position(p).Location()
+}
+
+// Location returns the position as a file:line:col string
+func (p Position) Location() string {
+	if p.Filename == "" {
+		return fmt.Sprintf("%d:%d", p.Row, p.Col)
+	}
+
+	return fmt.Sprintf("%s:%d:%d", p.Filename, p.Row, p.Col)
+}
@@ -1,29 +1,34 @@
 package golex
 
 import (
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"unicode/utf16"
 )
 
 var source string = " func() { test = \"SomeStringValue\"; test = 1.2; test = 88 }"
 var expected []Token = []Token{
-	{Type: TypeKeyword, Literal: "func", Position: Position{Row: 1, Col: 2, Cursor: 1}},
-	{Type: TypeOpenParen, Literal: "(", Position: Position{Row: 1, Col: 6, Cursor: 5}},
-	{Type: TypeCloseParen, Literal: ")", Position: Position{Row: 1, Col: 7, Cursor: 6}},
-	{Type: TypeOpenCurly, Literal: "{", Position: Position{Row: 1, Col: 9, Cursor: 8}},
-	{Type: TypeSymbol, Literal: "test", Position: Position{Row: 1, Col: 11, Cursor: 10}},
-	{Type: TypeAssign, Literal: "=", Position: Position{Row: 1, Col: 16, Cursor: 15}},
-	{Type: TypeDoubleQuoteString, Literal: "\"SomeStringValue\"", Value: "SomeStringValue", Position: Position{Row: 1, Col: 18, Cursor: 17}},
-	{Type: TypeSemicolon, Literal: ";", Position: Position{Row: 1, Col: 35, Cursor: 34}},
-	{Type: TypeSymbol, Literal: "test", Position: Position{Row: 1, Col: 37, Cursor: 36}},
-	{Type: TypeAssign, Literal: "=", Position: Position{Row: 1, Col: 42, Cursor: 41}},
-	{Type: TypeFloat, Literal: "1.2", Value: 1.2, Position: Position{Row: 1, Col: 44, Cursor: 43}},
-	{Type: TypeSemicolon, Literal: ";", Position: Position{Row: 1, Col: 47, Cursor: 46}},
-	{Type: TypeSymbol, Literal: "test", Position: Position{Row: 1, Col: 49, Cursor: 48}},
-	{Type: TypeAssign, Literal: "=", Position: Position{Row: 1, Col: 54, Cursor: 53}},
-	{Type: TypeInteger, Literal: "88", Value: 88, Position: Position{Row: 1, Col: 56, Cursor: 55}},
-	{Type: TypeCloseCurly, Literal: "}", Position: Position{Row: 1, Col: 59, Cursor: 58}},
-	{Type: TypeEof, Literal: string(EOF), Position: Position{Row: 1, Col: 60, Cursor: 59}},
+	{Type: TypeKeyword, Literal: "func", Position: Position{Row: 1, Col: 2, Cursor: 1}, End: Position{Row: 1, Col: 6, Cursor: 5}},
+	{Type: TypeOpenParen, Literal: "(", Position: Position{Row: 1, Col: 6, Cursor: 5}, End: Position{Row: 1, Col: 7, Cursor: 6}},
+	{Type: TypeCloseParen, Literal: ")", Position: Position{Row: 1, Col: 7, Cursor: 6}, End: Position{Row: 1, Col: 8, Cursor: 7}},
+	{Type: TypeOpenCurly, Literal: "{", Position: Position{Row: 1, Col: 9, Cursor: 8}, End: Position{Row: 1, Col: 10, Cursor: 9}},
+	{Type: TypeSymbol, Literal: "test", Position: Position{Row: 1, Col: 11, Cursor: 10}, End: Position{Row: 1, Col: 15, Cursor: 14}},
+	{Type: TypeAssign, Literal: "=", Position: Position{Row: 1, Col: 16, Cursor: 15}, End: Position{Row: 1, Col: 17, Cursor: 16}},
+	{Type: TypeDoubleQuoteString, Literal: "\"SomeStringValue\"", Value: "SomeStringValue", Position: Position{Row: 1, Col: 18, Cursor: 17}, End: Position{Row: 1, Col: 35, Cursor: 34}},
+	{Type: TypeSemicolon, Literal: ";", Position: Position{Row: 1, Col: 35, Cursor: 34}, End: Position{Row: 1, Col: 36, Cursor: 35}},
+	{Type: TypeSymbol, Literal: "test", Position: Position{Row: 1, Col: 37, Cursor: 36}, End: Position{Row: 1, Col: 41, Cursor: 40}},
+	{Type: TypeAssign, Literal: "=", Position: Position{Row: 1, Col: 42, Cursor: 41}, End: Position{Row: 1, Col: 43, Cursor: 42}},
+	{Type: TypeFloat, Literal: "1.2", Value: 1.2, Position: Position{Row: 1, Col: 44, Cursor: 43}, End: Position{Row: 1, Col: 47, Cursor: 46}},
+	{Type: TypeSemicolon, Literal: ";", Position: Position{Row: 1, Col: 47, Cursor: 46}, End: Position{Row: 1, Col: 48, Cursor: 47}},
+	{Type: TypeSymbol, Literal: "test", Position: Position{Row: 1, Col: 49, Cursor: 48}, End: Position{Row: 1, Col: 53, Cursor: 52}},
+	{Type: TypeAssign, Literal: "=", Position: Position{Row: 1, Col: 54, Cursor: 53}, End: Position{Row: 1, Col: 55, Cursor: 54}},
+	{Type: TypeInteger, Literal: "88", Value: 88, Position: Position{Row: 1, Col: 56, Cursor: 55}, End: Position{Row: 1, Col: 58, Cursor: 57}},
+	{Type: TypeCloseCurly, Literal: "}", Position: Position{Row: 1, Col: 59, Cursor: 58}, End: Position{Row: 1, Col: 60, Cursor: 59}},
+	{Type: TypeEof, Literal: string(EOF), Position: Position{Row: 1, Col: 60, Cursor: 59}, End: Position{Row: 1, Col: 60, Cursor: 59}},
 }
 
 func getLexer() *Lexer {
@@ -99,14 +104,14 @@ func TestMultiLinePosition(t *testing.T) {
 	}
 
 	expect := []Token{
-		{Type: TypeSymbol, Literal: "a", Position: Position{Col: 1, Row: 1, Cursor: 0}},
-		{Type: TypeAssign, Literal: "=", Position: Position{Col: 3, Row: 1, Cursor: 2}},
-		{Type: TypeBool, Literal: "true", Value: true, Position: Position{Col: 5, Row: 1, Cursor: 4}},
-		{Type: TypeSemicolon, Literal: ";", Position: Position{Col: 9, Row: 1, Cursor: 8}},
-		{Type: TypeSymbol, Literal: "b", Position: Position{Col: 1, Row: 2, Cursor: 10}},
-		{Type: TypeAssign, Literal: "=", Position: Position{Col: 3, Row: 2, Cursor: 12}},
-		{Type: TypeBool, Literal: "false", Value: false, Position: Position{Col: 5, Row: 2, Cursor: 14}},
-		{Type: TypeEof, Literal: string(EOF), Position: Position{Col: 10, Row: 2, Cursor: 19}},
+		{Type: TypeSymbol, Literal: "a", Position: Position{Col: 1, Row: 1, Cursor: 0}, End: Position{Col: 2, Row: 1, Cursor: 1}},
+		{Type: TypeAssign, Literal: "=", Position: Position{Col: 3, Row: 1, Cursor: 2}, End: Position{Col: 4, Row: 1, Cursor: 3}},
+		{Type: TypeBool, Literal: "true", Value: true, Position: Position{Col: 5, Row: 1, Cursor: 4}, End: Position{Col: 9, Row: 1, Cursor: 8}},
+		{Type: TypeSemicolon, Literal: ";", Position: Position{Col: 9, Row: 1, Cursor: 8}, End: Position{Col: 10, Row: 1, Cursor: 9}},
+		{Type: TypeSymbol, Literal: "b", Position: Position{Col: 1, Row: 2, Cursor: 10}, End: Position{Col: 2, Row: 2, Cursor: 11}},
+		{Type: TypeAssign, Literal: "=", Position: Position{Col: 3, Row: 2, Cursor: 12}, End: Position{Col: 4, Row: 2, Cursor: 13}},
+		{Type: TypeBool, Literal: "false", Value: false, Position: Position{Col: 5, Row: 2, Cursor: 14}, End: Position{Col: 10, Row: 2, Cursor: 19}},
+		{Type: TypeEof, Literal: string(EOF), Position: Position{Col: 10, Row: 2, Cursor: 19}, End: Position{Col: 10, Row: 2, Cursor: 19}},
 	}
 
 	differ := &Differ{}
@@ -134,19 +139,19 @@ func TestRetainWhitespace(t *testing.T) {
 	}
 
 	expect := []Token{
-		{Type: TypeSymbol, Literal: "a", Position: Position{Col: 1, Row: 1, Cursor: 0}},
-		{Type: TypeSpace, Literal: " ", Position: Position{Col: 2, Row: 1, Cursor: 1}},
-		{Type: TypeAssign, Literal: "=", Position: Position{Col: 3, Row: 1, Cursor: 2}},
-		{Type: TypeSpace, Literal: " ", Position: Position{Col: 4, Row: 1, Cursor: 3}},
-		{Type: TypeBool, Literal: "true", Value: true, Position: Position{Col: 5, Row: 1, Cursor: 4}},
-		{Type: TypeSemicolon, Literal: ";", Position: Position{Col: 9, Row: 1, Cursor: 8}},
-		{Type: TypeNewline, Literal: "\n", Position: Position{Col: 10, Row: 1, Cursor: 9}},
-		{Type: TypeSymbol, Literal: "b", Position: Position{Col: 1, Row: 2, Cursor: 10}},
-		{Type: TypeSpace, Literal: " ", Position: Position{Col: 2, Row: 2, Cursor: 11}},
-		{Type: TypeAssign, Literal: "=", Position: Position{Col: 3, Row: 2, Cursor: 12}},
-		{Type: TypeSpace, Literal: " ", Position: Position{Col: 4, Row: 2, Cursor: 13}},
-		{Type: TypeBool, Literal: "false", Value: false, Position: Position{Col: 5, Row: 2, Cursor: 14}},
-		{Type: TypeEof, Literal: string(EOF), Position: Position{Col: 10, Row: 2, Cursor: 19}},
+		{Type: TypeSymbol, Literal: "a", Position: Position{Col: 1, Row: 1, Cursor: 0}, End: Position{Col: 2, Row: 1, Cursor: 1}},
+		{Type: TypeSpace, Literal: " ", Position: Position{Col: 2, Row: 1, Cursor: 1}, End: Position{Col: 3, Row: 1, Cursor: 2}},
+		{Type: TypeAssign, Literal: "=", Position: Position{Col: 3, Row: 1, Cursor: 2}, End: Position{Col: 4, Row: 1, Cursor: 3}},
+		{Type: TypeSpace, Literal: " ", Position: Position{Col: 4, Row: 1, Cursor: 3}, End: Position{Col: 5, Row: 1, Cursor: 4}},
+		{Type: TypeBool, Literal: "true", Value: true, Position: Position{Col: 5, Row: 1, Cursor: 4}, End: Position{Col: 9, Row: 1, Cursor: 8}},
+		{Type: TypeSemicolon, Literal: ";", Position: Position{Col: 9, Row: 1, Cursor: 8}, End: Position{Col: 10, Row: 1, Cursor: 9}},
+		{Type: TypeNewline, Literal: "\n", Position: Position{Col: 10, Row: 1, Cursor: 9}, End: Position{Col: 1, Row: 2, Cursor: 10}},
+		{Type: TypeSymbol, Literal: "b", Position: Position{Col: 1, Row: 2, Cursor: 10}, End: Position{Col: 2, Row: 2, Cursor: 11}},
+		{Type: TypeSpace, Literal: " ", Position: Position{Col: 2, Row: 2, Cursor: 11}, End: Position{Col: 3, Row: 2, Cursor: 12}},
+		{Type: TypeAssign, Literal: "=", Position: Position{Col: 3, Row: 2, Cursor: 12}, End: Position{Col: 4, Row: 2, Cursor: 13}},
+		{Type: TypeSpace, Literal: " ", Position: Position{Col: 4, Row: 2, Cursor: 13}, End: Position{Col: 5, Row: 2, Cursor: 14}},
+		{Type: TypeBool, Literal: "false", Value: false, Position: Position{Col: 5, Row: 2, Cursor: 14}, End: Position{Col: 10, Row: 2, Cursor: 19}},
+		{Type: TypeEof, Literal: string(EOF), Position: Position{Col: 10, Row: 2, Cursor: 19}, End: Position{Col: 10, Row: 2, Cursor: 19}},
 	}
 
 	differ := &Differ{}
@@ -174,8 +179,8 @@ func TestTripleBacktickString(t *testing.T) {
 	}
 
 	expect := []Token{
-		{Type: TypeTripleBacktickString, Literal: "```a string```", Value: "a string", Position: Position{Col: 1, Row: 1, Cursor: 0}},
-		{Type: TypeEof, Literal: string(EOF), Position: Position{Row: 1, Col: 15, Cursor: 14}},
+		{Type: TypeTripleBacktickString, Literal: "```a string```", Value: "a string", Position: Position{Col: 1, Row: 1, Cursor: 0}, End: Position{Col: 15, Row: 1, Cursor: 14}},
+		{Type: TypeEof, Literal: string(EOF), Position: Position{Row: 1, Col: 15, Cursor: 14}, End: Position{Row: 1, Col: 15, Cursor: 14}},
 	}
 
 	differ := &Differ{}
@@ -187,40 +192,82 @@ func TestTripleBacktickString(t *testing.T) {
 	}
 }
 
-func TestLexerLookaheadCache(t *testing.T) {
-	fmt.Println("TestLexerLookaheadCache...")
+func TestMultiCharTokenCursor(t *testing.T) {
+	fmt.Println("TestMultiCharTokenCursor...")
+
+	tokens, err := NewLexer().TokenizeToSlice("a==b/* c */d// e\nf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := []string{}
+	for _, token := range tokens {
+		types = append(types, token.Type.String())
+	}
+
+	differ := &Differ{}
+	differ.Compare([]string{"Symbol", "Equal", "Symbol", "Comment", "Symbol", "Comment", "Symbol", "EndOfFile"}, types)
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+}
+
+func TestSymbolStartCharacters(t *testing.T) {
+	fmt.Println("TestSymbolStartCharacters...")
+
+	tokens, err := NewLexer(SymbolCharacterMap("éa-z", "a-z")).TokenizeToSlice("éfoo é bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	literals := []string{}
+	for _, token := range tokens {
+		literals = append(literals, token.Literal)
+	}
+
+	differ := &Differ{}
+	differ.Compare([]string{"éfoo", "é", "bar", string(EOF)}, literals)
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+}
+
+func TestLexerLookahead(t *testing.T) {
+	fmt.Println("TestLexerLookahead...")
 
 	lexer := getLexer()
 	lexer.TokenizeManual(source)
 
 	lexer.Lookahead(2)
 
-	if lexer.state.LookaheadCache.count != 2 {
-		t.Errorf("Expected the lookahead cache to contain 2 items. %d items were found.", lexer.state.LookaheadCache.count)
+	if lexer.state.Lookahead.Len() != 2 {
+		t.Errorf("Expected the lookahead buffer to contain 2 items. %d items were found.", lexer.state.Lookahead.Len())
 	}
 
 	lexer.NextToken()
 
-	if lexer.state.LookaheadCache.count != 1 {
-		t.Errorf("Expected the lookahead cache to contain 1 item. %d items were found.", lexer.state.LookaheadCache.count)
+	if lexer.state.Lookahead.Len() != 1 {
+		t.Errorf("Expected the lookahead buffer to contain 1 item. %d items were found.", lexer.state.Lookahead.Len())
 	}
 
 	lexer.Lookahead(2)
 
-	if lexer.state.LookaheadCache.count != 2 {
-		t.Errorf("Expected the lookahead cache to contain 2 items. %d items were found.", lexer.state.LookaheadCache.count)
+	if lexer.state.Lookahead.Len() != 2 {
+		t.Errorf("Expected the lookahead buffer to contain 2 items. %d items were found.", lexer.state.Lookahead.Len())
 	}
 
 	lexer.NextToken()
 
-	if lexer.state.LookaheadCache.count != 1 {
-		t.Errorf("Expected the lookahead cache to contain 1 item. %d items were found.", lexer.state.LookaheadCache.count)
+	if lexer.state.Lookahead.Len() != 1 {
+		t.Errorf("Expected the lookahead buffer to contain 1 item. %d items were found.", lexer.state.Lookahead.Len())
 	}
 
 	lexer.NextToken()
 
-	if lexer.state.LookaheadCache.count != 0 {
-		t.Errorf("Expected the lookahead cache to contain 0 items. %d items were found.", lexer.state.LookaheadCache.count)
+	if lexer.state.Lookahead.Len() != 0 {
+		t.Errorf("Expected the lookahead buffer to contain 0 items. %d items were found.", lexer.state.Lookahead.Len())
 	}
 }
 
@@ -386,3 +433,770 @@ func TestTokenizationInsertOrderAfter(t *testing.T) {
 	}
 
 }
+
+func TestTokenTypeInfo(t *testing.T) {
+	fmt.Println("TestTokenTypeInfo...")
+
+	if ClosingPair(TypeOpenParen) != TypeCloseParen || OpeningPair(TypeCloseSquare) != TypeOpenSquare {
+		t.Errorf("Expected the brackets to be paired")
+	}
+
+	if !IsOperator(TypePlus) || !IsLiteral(TypeFloat) || !IsPunctuation(TypeSemicolon) || !IsBracket(TypeOpenCurly) || !IsTrivia(TypeComment) {
+		t.Errorf("Expected the build-in types to be categorised")
+	}
+
+	for _, bt := range buildInTypes {
+		if CategoryOf(bt) == CategoryNone {
+			t.Errorf("Expected build-in type %s to have a category", bt)
+		}
+	}
+
+	lexer := NewLexer(WithLiteralTokens(
+		LiteralToken{Type: Type("OpenAngleQuote"), Literal: "«", Info: TokenTypeInfo{Category: CategoryBracket, ClosedBy: Type("CloseAngleQuote")}},
+		LiteralToken{Type: Type("CloseAngleQuote"), Literal: "»", Info: TokenTypeInfo{Category: CategoryBracket, DisplayName: "'»'", OpenedBy: Type("OpenAngleQuote")}},
+	))
+
+	tokens, err := lexer.TokenizeToSlice("« a »")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tokens[0].Category() != CategoryBracket || ClosingPair(tokens[0].Type) != tokens[2].Type {
+		t.Errorf("Expected the user literal tokens to be paired brackets")
+	}
+
+	if DisplayName(tokens[2].Type) != "'»'" || DisplayName(tokens[0].Type) != "OpenAngleQuote" {
+		t.Errorf("Unexpected display names %s and %s", DisplayName(tokens[0].Type), DisplayName(tokens[2].Type))
+	}
+}
+
+func TestDiagnosticRendering(t *testing.T) {
+	fmt.Println("TestDiagnosticRendering...")
+
+	d := Diagnostic{
+		Severity: SeverityWarning,
+		Code:     "W001",
+		Message:  "shadowed variable",
+		Source:   []rune("let x = 1\n\tlet x = \"abc\"\n"),
+		Labels: []Label{
+			PrimaryLabel(Position{Row: 2, Col: 6, Cursor: 15}, Position{Row: 2, Col: 7, Cursor: 16}, "shadows the outer x"),
+			SecondaryLabel(Position{Row: 1, Col: 5, Cursor: 4}, Position{Row: 1, Col: 6, Cursor: 5}, "declared here"),
+		},
+		Help: []string{"rename the variable"},
+	}
+
+	expect := "warning[W001]: shadowed variable\n" +
+		" --> 2:6\n" +
+		"  |\n" +
+		"1 | let x = 1\n" +
+		"  |     - declared here\n" +
+		"2 | \tlet x = \"abc\"\n" +
+		"  | \t    ^ shadows the outer x\n" +
+		"  |\n" +
+		"  = help: rename the variable\n"
+
+	if d.String() != expect {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expect, d.String())
+	}
+
+	lexer := getLexer()
+	_, err := lexer.TokenizeToSlice("a = 1;\n  b = \"abc")
+	if err == nil {
+		t.Fatal("Expected an unterminated string error")
+	}
+
+	expectErr := "  2:   7: Unterminated string literal\n" +
+		"2 |   b = \"abc\n" +
+		"  |       ^^^^"
+
+	if err.Error() != expectErr {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expectErr, err.Error())
+	}
+
+	// Unterminated tokens end at the end of the source
+	for source, expect := range map[string]string{
+		`"abc`:   "error[L0002]: Unterminated string literal\n --> 1:1\n  |\n1 | \"abc\n  | ^^^^\n",
+		`/* abc`: "error[L0003]: Unterminated comment\n --> 1:1\n  |\n1 | /* abc\n  | ^^^^^^\n",
+	} {
+		_, err := NewLexer().TokenizeToSlice(source)
+
+		var lexErr *Error
+		if !errors.As(err, &lexErr) {
+			t.Fatalf("Expected a lexer error for %q but got %v", source, err)
+		}
+
+		if end := len([]rune(source)); lexErr.End.Cursor != end || lexErr.End.Col != end+1 {
+			t.Errorf("Expected %q to end at %d but got %d", source, end, lexErr.End.Cursor)
+		}
+
+		if diagnostic := lexErr.Diagnostic().String(); diagnostic != expect {
+			t.Errorf("Expected:\n%s\nGot:\n%s", expect, diagnostic)
+		}
+	}
+}
+
+func TestErrorKinds(t *testing.T) {
+	fmt.Println("TestErrorKinds...")
+
+	cases := []struct {
+		source  string
+		options []LexerOptionFunc
+		kind    *ErrorKind
+	}{
+		{source: "a = §", kind: ErrInvalidCharacter},
+		{source: "a = \"abc", kind: ErrUnterminatedString},
+		{source: "a = \"abc\\\"", kind: ErrUnterminatedString},
+		{source: "a /* comment", kind: ErrUnterminatedComment},
+		{source: "a = 1.2.3", kind: ErrMalformedNumber},
+		{source: "a = \"\\q\"", kind: ErrInvalidEscape, options: []LexerOptionFunc{ValidateEscapeSequences()}},
+	}
+
+	for _, c := range cases {
+		_, err := NewLexer(c.options...).TokenizeToSlice(c.source)
+		if !errors.Is(err, c.kind) {
+			t.Errorf("Expected %q to fail with %s but got %v", c.source, c.kind, err)
+		}
+
+		var lexErr *Error
+		if !errors.As(err, &lexErr) || lexErr.Position.Row != 1 {
+			t.Errorf("Expected %q to fail with a positioned *Error but got %v", c.source, err)
+		}
+	}
+
+	if _, err := NewLexer(ValidateEscapeSequences()).TokenizeToSlice(`"a\n\"\\\x41\u00e9\101"`); err != nil {
+		t.Errorf("Expected valid escape sequences but got %v", err)
+	}
+
+	_, err := NewLexer().TokenizeToSlice("a = \"abc")
+	if !IsIncomplete(err) {
+		t.Errorf("Expected an unterminated string to be incomplete")
+	}
+
+	// The label of the error spans the erroneous token
+	_, err = NewLexer().TokenizeToSlice("a = 1.2.3")
+	label := err.(*Error).Diagnostic().Labels[0]
+	if label.Start.Col != 5 || label.End.Col != 10 {
+		t.Errorf("Expected the label to span columns 5 up to 10 but got %s up to %s", label.Start, label.End)
+	}
+}
+
+func TestTokenSpans(t *testing.T) {
+	fmt.Println("TestTokenSpans...")
+
+	src := "a /* multi\nline */ b // trailing\nc == d"
+	tokens, err := NewLexer(RetainWhitespace()).TokenizeToSlice(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source := []rune(src)
+	for _, token := range tokens {
+		if token.TypeIs(TypeEof) {
+			continue
+		}
+
+		expect := SpanFromOffsets(source, token.Position.Cursor, token.Position.Cursor+token.Span().Len())
+		if token.Span() != expect {
+			t.Errorf("Expected the span of %s to be %s but got %s", token.Type, expect, token.Span())
+		}
+	}
+
+	comment := tokens[2]
+	if !comment.TypeIs(TypeComment) || comment.Span().Text(source) != "/* multi\nline */" {
+		t.Errorf("Expected the multi-line comment span to include the closer but got %q", comment.Span().Text(source))
+	}
+
+	if comment.End != (Position{Row: 2, Col: 8, Cursor: 18}) {
+		t.Errorf("Unexpected end position of the comment %+v", comment.End)
+	}
+
+	trailing := tokens[6]
+	if !trailing.TypeIs(TypeComment) || !tokens[7].TypeIs(TypeNewline) {
+		t.Errorf("Expected the single line comment to be followed by a newline but got %s", tokens[7].Type)
+	}
+
+	equal := tokens[10]
+	if !equal.TypeIs(TypeEqual) || equal.Span().Len() != 2 || !tokens[11].TypeIs(TypeSpace) {
+		t.Errorf("Expected a two character equal token but got %s of length %d", equal.Type, equal.Span().Len())
+	}
+
+	a, b := tokens[0].Span(), tokens[4].Span()
+	union := a.Union(b)
+	if !union.ContainsSpan(comment.Span()) || !union.Overlaps(comment.Span()) || a.Overlaps(b) || !union.Contains(comment.End) {
+		t.Errorf("Unexpected span utility results for %s and %s", a, b)
+	}
+}
+
+func TestIgnoreTokens(t *testing.T) {
+	fmt.Println("TestIgnoreTokens...")
+
+	lexer := NewLexer(IgnoreTokens(TypeSemicolon), IgnoreComments())
+
+	tokens, err := lexer.TokenizeToSlice("a; /* comment */ b // comment\nc;")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	literals := []string{}
+	for _, token := range tokens {
+		literals = append(literals, token.Literal)
+	}
+
+	differ := &Differ{}
+	differ.Compare([]string{"a", "b", "c", string(EOF)}, literals)
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+}
+
+func TestFileSet(t *testing.T) {
+	fmt.Println("TestFileSet...")
+
+	fset := NewFileSet()
+	first := fset.AddFile("first.dsl", "a = 1;\nb = 2;")
+	second := fset.AddFile("second.dsl", "c = \"abc")
+
+	lexer := getLexer()
+	tokens, err := lexer.TokenizeFile(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, token := range tokens {
+		if fset.File(token.Pos) != first {
+			t.Errorf("Expected the token %s to resolve to the first file", token.Type)
+		}
+
+		if position := fset.Position(token.Pos); position != token.Position {
+			t.Errorf("Expected pos %d to resolve to %+v but got %+v", token.Pos, token.Position, position)
+		}
+	}
+
+	if location := fset.Location(tokens[4].Pos); location != "first.dsl:2:1" {
+		t.Errorf("Expected the location first.dsl:2:1 but got %s", location)
+	}
+
+	_, err = lexer.TokenizeFile(second)
+	if err == nil || !strings.HasPrefix(err.Error(), "second.dsl:1:5: Unterminated string literal") {
+		t.Errorf("Expected the error to include the filename but got %v", err)
+	}
+
+	for token := range lexer.IterateNamed("named.dsl", "x") {
+		if token.Position.Filename != "named.dsl" || token.Pos.IsValid() {
+			t.Errorf("Expected a named position without pos but got %+v", token)
+		}
+	}
+}
+
+func TestPositionEncodings(t *testing.T) {
+	fmt.Println("TestPositionEncodings...")
+
+	src := "é = \"𝄞\";\n😀 = 1"
+	lexer := NewLexer(TrackByteOffsets(), TrackUTF16Columns(), SymbolCharacterMap("a-z", "a-z"))
+	lexer.SymbolStartCharacterMap += "é😀"
+
+	tokens, err := lexer.TokenizeToSlice(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, token := range tokens {
+		expect := PositionWithEncodings(src, token.Position.Cursor)
+		if token.Position != expect {
+			t.Errorf("Expected the position of %q to be %+v but got %+v", token.Literal, expect, token.Position)
+		}
+
+		if RuneOffsetToByteOffset(src, token.Position.Cursor) != token.Position.Offset {
+			t.Errorf("Expected the byte offset of %q to be %d", token.Literal, token.Position.Offset)
+		}
+	}
+
+	semicolon := tokens[3]
+	if semicolon.Position.Col != 8 || semicolon.Position.UTF16Col != 9 || semicolon.Position.Offset != 11 {
+		t.Errorf("Unexpected position of the semicolon %+v", semicolon.Position)
+	}
+
+	if RuneColumnToUTF16Column("😀 = 1", 3) != 4 || UTF16ColumnToRuneColumn("😀 = 1", 4) != 3 {
+		t.Errorf("Unexpected UTF-16 column conversion")
+	}
+
+	if ByteOffsetToRuneOffset(src, 11) != 7 || RuneColumnToByteColumn("é = 1", 2) != 3 || ByteColumnToRuneColumn("é = 1", 3) != 2 {
+		t.Errorf("Unexpected byte offset conversion")
+	}
+}
+
+func TestVisualColumns(t *testing.T) {
+	fmt.Println("TestVisualColumns...")
+
+	src := "\tname = \"你好\"; x\n  y"
+	lexer := NewLexer(TrackVisualColumns(4))
+	tokens, err := lexer.TokenizeToSlice(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cols := []int{}
+	for _, token := range tokens {
+		cols = append(cols, token.Position.VisualCol)
+
+		line := strings.Split(src, "\n")[token.Position.Row-1]
+		if expect := VisualColumn(line, token.Position.Col, 4); token.Position.VisualCol != expect {
+			t.Errorf("Expected the visual column of %q to be %d but got %d", token.Literal, expect, token.Position.VisualCol)
+		}
+	}
+
+	differ := &Differ{}
+	differ.Compare([]int{5, 10, 12, 18, 20, 3, 4}, cols)
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+
+	d := Diagnostic{
+		Message: "wide",
+		Source:  []rune(src),
+		Labels:  []Label{PrimaryLabel(tokens[2].Position, tokens[2].End, "here"), SecondaryLabel(tokens[4].Position, tokens[4].End, "")},
+	}
+
+	var sb strings.Builder
+	DiagnosticRenderer{TabWidth: 4}.Render(&sb, d)
+
+	expect := "error: wide\n" +
+		" --> 1:9\n" +
+		"  |\n" +
+		"1 |     name = \"你好\"; x\n" +
+		"  |            ^^^^^^ here\n" +
+		"  |                    -\n"
+
+	if sb.String() != expect {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expect, sb.String())
+	}
+}
+
+func TestRuneWidth(t *testing.T) {
+	fmt.Println("TestRuneWidth...")
+
+	cases := map[rune]int{
+		'a': 1, 'é': 1, '\u0301': 0, '\u200B': 0, '你': 2, 'Ｆ': 2, '한': 2,
+		// Emoji presentation symbols are wide
+		'\U0001F300': 2, '\U0001F37A': 2, '\U0001F600': 2, '\U0001F680': 2, '\U0001F9D0': 2,
+		// Symbols in the same blocks defaulting to text presentation are narrow
+		'\U0001F321': 1, '\U0001F336': 1, '\U0001F37D': 1, '\U0001F3CB': 1, '\U0001F3F5': 1,
+		'\U0001F43F': 1, '\U0001F441': 1, '\U0001F54A': 1, '\U0001F5A5': 1, '\U0001F5FA': 1,
+		'\U0001F6E0': 1, '\U0001F6F3': 1,
+	}
+
+	for r, width := range cases {
+		if got := RuneWidth(r); got != width {
+			t.Errorf("Expected the width of U+%04X to be %d but got %d", r, width, got)
+		}
+	}
+
+	// A narrow symbol followed by a variation selector only takes up its own column
+	if width := StringWidth("\U0001F321\uFE0F", 4); width != 1 {
+		t.Errorf("Expected a width of 1 but got %d", width)
+	}
+}
+
+func TestLineTable(t *testing.T) {
+	fmt.Println("TestLineTable...")
+
+	src := []rune("one\r\ntwo\rthree\u2028four\n\nsix")
+	table := NewLineTable(src)
+
+	lines := []string{}
+	for line := 1; line <= table.LineCount(); line++ {
+		lines = append(lines, table.LineText(line))
+	}
+
+	differ := &Differ{}
+	differ.Compare([]string{"one", "two", "three", "four", "", "six"}, lines)
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+
+	offsets := []int{0, 3, 4, 5, 8, 9, 15, 20, 21, 24}
+	rows := []int{}
+	for _, offset := range offsets {
+		position := table.PositionOf(offset)
+		rows = append(rows, position.Row)
+
+		if table.OffsetOf(position.Row, position.Col) != offset {
+			t.Errorf("Expected offset %d to round trip but got %d", offset, table.OffsetOf(position.Row, position.Col))
+		}
+	}
+
+	differ.Compare([]int{1, 1, 1, 2, 2, 3, 4, 5, 6, 6}, rows)
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+
+	lexer := NewLexer()
+
+	tokens := []Token{}
+	for token, err := range lexer.Iterate("a\r\nb\rc") {
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	if tokens[1].Position.Row != 2 || tokens[1].Position.Col != 1 || tokens[2].Position.Row != 3 {
+		t.Errorf("Expected b at 2:1 and c on line 3 but got %s and %s", tokens[1].Position, tokens[2].Position)
+	}
+
+	if line, start := lexer.GetCurrentLine(); line != 3 || start != 5 {
+		t.Errorf("Expected the current line to be 3 starting at 5 but got %d starting at %d", line, start)
+	}
+}
+
+func TestOffsetsWithoutLineTable(t *testing.T) {
+	fmt.Println("TestOffsetsWithoutLineTable...")
+
+	source := []rune("one\r\ntwo\rthree\u2028\n\nsix")
+	table := NewLineTable(source)
+
+	for offset := -1; offset <= len(source)+1; offset++ {
+		if position, expect := PositionFromOffset(source, offset), table.PositionOf(offset); position != expect {
+			t.Errorf("Expected the position of offset %d to be %s but got %s", offset, expect, position)
+		}
+	}
+
+	for line := 0; line <= table.LineCount()+1; line++ {
+		if text, expect := lineText(source, NewlinesUnicode, line), table.LineText(line); text != expect {
+			t.Errorf("Expected the text of line %d to be %q but got %q", line, expect, text)
+		}
+	}
+
+	if span := SpanFromOffsets(source, 5, 12); span != table.SpanOf(5, 12) {
+		t.Errorf("Unexpected span %s", span)
+	}
+
+	err := NewErrorInLines(ErrUnexpectedToken, "unexpected", table.PositionOf(7), table)
+	if err.Snippet != "two" || err.Diagnostic().Lines != table {
+		t.Errorf("Expected the error to share the line table but got %q", err.Snippet)
+	}
+}
+
+func TestNewlines(t *testing.T) {
+	fmt.Println("TestNewlines...")
+
+	src := "a // one\r\nb\rc\u2028d"
+
+	tokens, err := NewLexer(RetainWhitespace()).TokenizeToSlice(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Token{
+		{Type: TypeSymbol, Literal: "a", Position: Position{Row: 1, Col: 1, Cursor: 0}, End: Position{Row: 1, Col: 2, Cursor: 1}},
+		{Type: TypeSpace, Literal: " ", Position: Position{Row: 1, Col: 2, Cursor: 1}, End: Position{Row: 1, Col: 3, Cursor: 2}},
+		{Type: TypeComment, Literal: "// one", Position: Position{Row: 1, Col: 3, Cursor: 2}, End: Position{Row: 1, Col: 9, Cursor: 8}},
+		{Type: TypeNewline, Literal: "\r\n", Position: Position{Row: 1, Col: 9, Cursor: 8}, End: Position{Row: 2, Col: 1, Cursor: 10}},
+		{Type: TypeSymbol, Literal: "b", Position: Position{Row: 2, Col: 1, Cursor: 10}, End: Position{Row: 2, Col: 2, Cursor: 11}},
+		{Type: TypeNewline, Literal: "\r", Position: Position{Row: 2, Col: 2, Cursor: 11}, End: Position{Row: 3, Col: 1, Cursor: 12}},
+		{Type: TypeSymbol, Literal: "c", Position: Position{Row: 3, Col: 1, Cursor: 12}, End: Position{Row: 3, Col: 2, Cursor: 13}},
+		{Type: TypeNewline, Literal: "\u2028", Position: Position{Row: 3, Col: 2, Cursor: 13}, End: Position{Row: 4, Col: 1, Cursor: 14}},
+		{Type: TypeSymbol, Literal: "d", Position: Position{Row: 4, Col: 1, Cursor: 14}, End: Position{Row: 4, Col: 2, Cursor: 15}},
+		{Type: TypeEof, Literal: string(EOF), Position: Position{Row: 4, Col: 2, Cursor: 15}, End: Position{Row: 4, Col: 2, Cursor: 15}},
+	}
+
+	differ := &Differ{}
+	differ.Compare(expected, tokens)
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+
+	// Only recognising "\n" leaves the carriage returns in the line
+	tokens, err = NewLexer(RetainWhitespace(), WithNewlines(NewlinesUnix)).TokenizeToSlice("a\r\nb\rc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := []TokenType{}
+	for _, token := range tokens {
+		types = append(types, token.Type)
+	}
+
+	differ.Compare([]TokenType{TypeSymbol, TypeCarriageReturn, TypeNewline, TypeSymbol, TypeCarriageReturn, TypeSymbol, TypeEof}, types)
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+
+	if c := tokens[5]; c.Position.Row != 2 || c.Position.Col != 3 {
+		t.Errorf("Expected c at 2:3 but got %s", c.Position)
+	}
+
+	// The zero value recognises all line terminators
+	lexer := NewLexer(WithNewlines(0))
+	lexer.TokenizeManual("a\rb")
+	if lexer.Lines().Newlines() != NewlinesUnicode || lexer.Lines().LineCount() != 2 {
+		t.Errorf("Expected the zero Newlines to recognise all line terminators")
+	}
+
+	// Files keep the line terminators they were added with, so their positions match the FileSet
+	fset := NewFileSet()
+	file := fset.AddFileWith("unix.dsl", "a\rb\nc", NewlinesUnix)
+	tokens, err = NewLexer(WithNewlines(NewlinesUnix)).TokenizeFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, token := range tokens {
+		if position := fset.Position(token.Pos); position != token.Position {
+			t.Errorf("Expected the FileSet position of %q to be %s but got %s", token.Literal, token.Position, position)
+		}
+	}
+
+	if c := tokens[len(tokens)-2]; c.Position.Row != 2 || file.Lines().Newlines() != NewlinesUnix {
+		t.Errorf("Expected c on line 2 but got %s", c.Position)
+	}
+}
+
+func TestSourceEncodings(t *testing.T) {
+	fmt.Println("TestSourceEncodings...")
+
+	src := "name = \"héllo 😀\";\nx"
+	expected, err := NewLexer().TokenizeToSlice(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encode := func(bom []byte, unit func(r rune) []byte) []byte {
+		data := slices.Clone(bom)
+		for _, r := range src {
+			data = append(data, unit(r)...)
+		}
+
+		return data
+	}
+
+	utf16Units := func(bigEndian bool) func(r rune) []byte {
+		return func(r rune) []byte {
+			data := []byte{}
+			for _, u := range utf16.Encode([]rune{r}) {
+				if bigEndian {
+					data = append(data, byte(u>>8), byte(u))
+				} else {
+					data = append(data, byte(u), byte(u>>8))
+				}
+			}
+
+			return data
+		}
+	}
+
+	sources := map[Encoding][]byte{
+		EncodingUTF8:    append([]byte{0xef, 0xbb, 0xbf}, src...),
+		EncodingUTF16LE: encode([]byte{0xff, 0xfe}, utf16Units(false)),
+		EncodingUTF16BE: encode([]byte{0xfe, 0xff}, utf16Units(true)),
+		EncodingUTF32LE: encode([]byte{0xff, 0xfe, 0, 0}, func(r rune) []byte { return []byte{byte(r), byte(r >> 8), byte(r >> 16), 0} }),
+		EncodingUTF32BE: encode([]byte{0, 0, 0xfe, 0xff}, func(r rune) []byte { return []byte{0, byte(r >> 16), byte(r >> 8), byte(r)} }),
+	}
+
+	for encoding, data := range sources {
+		if detected, _ := DetectEncoding(data); detected != encoding {
+			t.Errorf("Expected %s to be detected but got %s", encoding, detected)
+		}
+
+		if decoded, _, err := DecodeSource(data); err != nil || decoded != src {
+			t.Errorf("Expected %s to decode to %q but got %q (%v)", encoding, src, decoded, err)
+		}
+
+		tokens := []Token{}
+		for token, err := range NewLexer().IterateBytes(data) {
+			if err != nil {
+				t.Fatalf("Unexpected error lexing %s: %s", encoding, err)
+			}
+
+			tokens = append(tokens, token)
+		}
+
+		differ := &Differ{}
+		differ.Compare(expected, tokens)
+		if differ.HasDifference() {
+			fmt.Println(encoding, differ)
+			t.FailNow()
+		}
+	}
+
+	// Invalid UTF-8 is reported at its position, also inside strings
+	for _, invalid := range []string{"a = \"b\xffc\"", "a = \xff"} {
+		_, err := NewLexer().TokenizeToSlice(invalid)
+
+		var lexErr *Error
+		if !errors.Is(err, ErrInvalidEncoding) || !errors.As(err, &lexErr) {
+			t.Fatalf("Expected an invalid encoding error for %q but got %v", invalid, err)
+		}
+
+		if offset := strings.IndexByte(invalid, 0xff); lexErr.Position.Cursor != offset || lexErr.Position.Col != offset+1 {
+			t.Errorf("Expected the invalid encoding at %d but got %s", offset, lexErr.Position)
+		}
+	}
+
+	if _, _, err := DecodeSource([]byte{0xff, 0xfe, 0x3d, 0xd8, 'a', 0}); !errors.Is(err, ErrInvalidEncoding) {
+		t.Errorf("Expected an unpaired surrogate to be an invalid encoding but got %v", err)
+	}
+}
+
+func TestLexerMarkReset(t *testing.T) {
+	fmt.Println("TestLexerMarkReset...")
+
+	lexer := getLexer()
+	lexer.TokenizeManual(source)
+
+	next := func(count int) []Token {
+		tokens := []Token{}
+		for range count {
+			token, err := lexer.NextToken()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tokens = append(tokens, token)
+		}
+
+		return tokens
+	}
+
+	differ := &Differ{}
+
+	// Reset to a mark of which the tokens are still buffered
+	lexer.NextToken()
+	mark := lexer.Mark()
+	lexer.Peek(4)
+	differ.Compare(expected[1:4], next(3))
+	lexer.Reset(mark)
+	differ.Compare(expected[1:6], next(5))
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+
+	// Reset further back than the buffer retains, the tokens are scanned again
+	mark = lexer.Mark()
+	lexer.Peek(3)
+	next(20)
+	lexer.Reset(mark)
+	if peeked := lexer.Peek(2); !peeked.Is(expected[7]) {
+		t.Errorf("Expected to peek %s but got %s", expected[7].Literal, peeked.Literal)
+	}
+
+	differ.Compare(expected[6:], next(len(expected)-6))
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+
+	// Peeking past the end repeatedly returns the EOF token
+	if !lexer.Peek(1).TypeIs(TypeEof) || !lexer.Peek(100).TypeIs(TypeEof) {
+		t.Errorf("Expected EOF tokens past the end but got %s and %s", lexer.Peek(1).Type, lexer.Peek(100).Type)
+	}
+
+	// Identical tokens are buffered separately
+	lexer.TokenizeManual("a a a b")
+	if !lexer.Peek(3).Is(Token{Type: TypeSymbol, Literal: "a"}) || !lexer.Peek(4).Is(Token{Type: TypeSymbol, Literal: "b"}) {
+		t.Errorf("Expected the lookahead to contain all identical tokens")
+	}
+}
+
+func TestLexerFork(t *testing.T) {
+	fmt.Println("TestLexerFork...")
+
+	lexer := getLexer()
+	lexer.TokenizeManual(source)
+	lexer.NextToken()
+	lexer.Peek(3)
+
+	fork := lexer.Fork()
+	for range 5 {
+		if _, err := fork.NextToken(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fork.Peek(10)
+
+	// The fork does not move the lexer or change its lookahead
+	if next := lexer.Peek(1); !next.Is(expected[1]) || lexer.state.Lookahead.Len() != 3 {
+		t.Errorf("Expected the lexer to remain at %s with 3 buffered tokens but got %s with %d", expected[1].Literal, next.Literal, lexer.state.Lookahead.Len())
+	}
+
+	if err := lexer.Join(fork); err != nil {
+		t.Fatal(err)
+	}
+
+	tokens := []Token{}
+	for !lexer.ReachedEOF() {
+		token, err := lexer.NextToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	differ := &Differ{}
+	differ.Compare(expected[6:], tokens)
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+
+	// The fork keeps its own position after joining
+	if next := fork.Peek(1); !next.Is(expected[6]) {
+		t.Errorf("Expected the fork to remain at %s but got %s", expected[6].Literal, next.Literal)
+	}
+}
+
+func TestLexerForkConcurrent(t *testing.T) {
+	fmt.Println("TestLexerForkConcurrent...")
+
+	src := strings.Repeat("a = \"abc\" /* comment */ b => c;\n", 200)
+
+	lexer := getLexer()
+	lexer.TokenizeManual(src)
+	forks := []*Lexer{lexer, lexer.Fork(), lexer.Fork(), lexer.Fork()}
+
+	results := make([][]Token, len(forks))
+	var wg sync.WaitGroup
+	for i, fork := range forks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !fork.ReachedEOF() {
+				token, err := fork.NextToken()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				results[i] = append(results[i], token)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	differ := &Differ{}
+	for _, result := range results[1:] {
+		differ.Compare(results[0], result)
+		if differ.HasDifference() {
+			fmt.Println(differ)
+			t.FailNow()
+		}
+	}
+
+	// Only forks of the lexer can be joined
+	other := getLexer()
+	other.TokenizeManual(src)
+	if err := lexer.Join(other); !errors.Is(err, ErrNotFork) {
+		t.Errorf("Expected ErrNotFork but got %v", err)
+	}
+}
@@ -0,0 +1,28 @@
+// Package golextest provides the fixtures shared by the tests of the parsing packages
+package golextest
+
+import (
+	"testing"
+
+	"github.com/cornejong/golex"
+)
+
+// Tokenize tokenizes the source using a lexer with the options, failing the test on a lexing error
+func Tokenize(tb testing.TB, source string, options ...golex.LexerOptionFunc) golex.Tokens {
+	tb.Helper()
+
+	tokens, err := golex.NewLexer(options...).TokenizeToSlice(source)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return tokens
+}
+
+// Collection tokenizes the source into a token collection, failing the test on a lexing error
+func Collection(tb testing.TB, source string, options ...golex.LexerOptionFunc) *golex.TokenCollection {
+	tb.Helper()
+
+	collection := golex.NewTokenCollection(Tokenize(tb, source, options...))
+	return &collection
+}
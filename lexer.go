@@ -23,66 +23,54 @@ type State struct {
 	PositionCursor int
 	Position       Position
 
-	LineIndexes      []int
-	LineIndexesCount int
-	CurrentToken     *Token
-	LookaheadCache   LookaheadCache
-}
+	Lines        *LineTable
+	File         *File
+	CurrentToken *Token
+	Lookahead    LookaheadBuffer
 
-type LookaheadCache struct {
-	tokens []Token
-	count  int
+	// Encoding is the detected encoding of the source
+	Encoding Encoding
+	// InvalidEncodings holds the sorted cursor offsets of invalid encoded characters,
+	// they are decoded as U+FFFD and reported as ErrInvalidEncoding errors
+	InvalidEncodings []int
 }
 
-func (lc *LookaheadCache) ContainsItems() bool { return lc.count > 0 }
-func (lc *LookaheadCache) ItemCount() int      { return lc.count }
+// NewFileState creates the state for lexing the file
+func NewFileState(file *File) State {
+	// The full slice expression makes appending the EOF sentinel copy the content
+	content := file.content[:len(file.content):len(file.content)]
 
-func (lc *LookaheadCache) AddItem(token Token) bool {
-	if slices.Contains(lc.tokens, token) {
-		return false // We already cached this token
-	}
+	state := newState(file.encoding, content, file.invalid)
+	state.File = file
+	state.Lines = file.Lines()
+	state.Position.Filename = file.Name()
 
-	lc.tokens = append(lc.tokens, token)
-	lc.count += 1
-	return true
+	return state
 }
 
-// TODO: this does not check for out of bounds stuff..
-// TODO: Probably not what we want to do...
-func (lc *LookaheadCache) PluckItem() Token {
-	token := lc.tokens[0]
-
-	lc.tokens = lc.tokens[1:]
-	lc.count -= 1
-
-	return token
+// NewState creates the state for lexing the content, recognising all line terminators.
+// A byte-order mark is stripped and UTF-16 and UTF-32 content is transcoded.
+func NewState(content string) State {
+	return NewStateWith(content, NewlinesUnicode)
 }
 
-func (lc *LookaheadCache) GetFirstItem() Token {
-	return lc.tokens[0]
-}
+// NewStateWith creates the state for lexing the content, only recognising the provided line terminators
+func NewStateWith(content string, newlines Newlines) State {
+	encoding, c, invalid := decodeSource(content)
+	state := newState(encoding, c, invalid)
+	state.Lines = NewLineTableWith(c, newlines)
 
-func (lc *LookaheadCache) GetItem(pos int) Token {
-	return lc.tokens[pos]
+	return state
 }
 
-func NewState(content string) State {
-	c := []rune(content)
-
-	lineIndexes := []int{}
-	for i, char := range c {
-		if char == '\n' {
-			lineIndexes = append(lineIndexes, i)
-		}
-	}
-
+func newState(encoding Encoding, c []rune, invalid []int) State {
 	return State{
 		Content:          append(c, EOF),
 		ContentLength:    len(c),
-		LineIndexes:      lineIndexes,
-		LineIndexesCount: len(lineIndexes),
 		PositionCursor:   0,
 		Position:         Position{Col: 1, Row: 1, Cursor: 0},
+		Encoding:         encoding,
+		InvalidEncodings: invalid,
 		CurrentToken: &Token{
 			Type:     TypeSof,
 			Position: Position{},
@@ -112,12 +100,28 @@ type Lexer struct {
 
 	IgnoreWhitespace           bool
 	IgnoreComments             bool
+	ValidateEscapeSequences    bool
 	UseBuiltinTypes            bool
 	CheckForKeywords           bool
 	SymbolStartCharacterMap    string
 	SymbolContinueCharacterMap string
 	DebugPrintTokens           bool
 	OmitTokenPosition          bool
+	TrackByteOffsets           bool
+	TrackUTF16Columns          bool
+	TrackVisualColumns         bool
+	TabWidth                   int
+	// Newlines are the recognised line terminators, the zero value recognises all of them like NewlinesUnicode.
+	// Files are lexed with the line terminators of their FileSet, see FileSet.AddFileWith.
+	Newlines Newlines
+
+	// err is the first error of the options, see Err
+	err error
+
+	// The matches of the last CanTokenize calls, consumed by the following Tokenize calls
+	cachedLiteralToken    *Token
+	cachedCommentSyntax   *CommentSyntax
+	cachedStringEnclosure *StringEnclosure
 }
 
 func NewLexer(options ...LexerOptionFunc) *Lexer {
@@ -142,6 +146,7 @@ func NewLexer(options ...LexerOptionFunc) *Lexer {
 		UseBuiltinTypes:            false,
 		SymbolStartCharacterMap:    defaultSymbolStartCharacterMapExpanded,
 		SymbolContinueCharacterMap: defaultSymbolContinueCharacterMapExpanded,
+		Newlines:                   NewlinesUnicode,
 	}
 
 	// Comment Tokenizer
@@ -175,6 +180,19 @@ func NewLexer(options ...LexerOptionFunc) *Lexer {
 	return lexer
 }
 
+// Err returns the first error of the options the lexer was created with, like a token type
+// name that is already registered as another type. Iterating a lexer with an error yields only that error.
+func (l *Lexer) Err() error {
+	return l.err
+}
+
+// optionError records the first error of the options
+func (l *Lexer) optionError(err error) {
+	if l.err == nil {
+		l.err = err
+	}
+}
+
 func (l *Lexer) RemoveTokenizer(tokenizerType TokenizerType) {
 	delete(l.tokenizers, tokenizerType)
 }
@@ -192,14 +210,63 @@ func (l *Lexer) TokenizeToSlice(content string) ([]Token, error) {
 	return tokens, nil
 }
 
+// TokenizeFile tokenizes the file into a slice of tokens
+func (l *Lexer) TokenizeFile(file *File) ([]Token, error) {
+	tokens := []Token{}
+	for token, err := range l.IterateFile(file) {
+		if err != nil {
+			return tokens, err
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	return tokens, nil
+}
+
 func (l *Lexer) TokenizeManual(content string) {
-	l.state = NewState(content)
+	l.setState(NewStateWith(content, l.newlines()))
+}
+
+// TokenizeFileManual prepares the lexer for manually tokenizing the file
+func (l *Lexer) TokenizeFileManual(file *File) {
+	l.setState(NewFileState(file))
 }
 
 func (l *Lexer) Iterate(content string) iter.Seq2[Token, error] {
-	l.state = NewState(content)
+	l.setState(NewStateWith(content, l.newlines()))
+	return l.iterate()
+}
+
+// IterateBytes iterates over the tokens of the raw source,
+// which may be UTF-8, or UTF-16 or UTF-32 with a byte-order mark
+func (l *Lexer) IterateBytes(data []byte) iter.Seq2[Token, error] {
+	return l.Iterate(string(data))
+}
+
+// IterateNamed iterates over the tokens of the named source.
+// The name is included in the token positions and errors.
+func (l *Lexer) IterateNamed(name string, content string) iter.Seq2[Token, error] {
+	l.setState(NewStateWith(content, l.newlines()))
+	l.state.Position.Filename = name
+	return l.iterate()
+}
+
+// IterateFile iterates over the tokens of a file registered in a FileSet.
+// The tokens carry the filename in their positions and their compact Pos.
+// The line terminators of the file are used, so the positions match FileSet.Position.
+func (l *Lexer) IterateFile(file *File) iter.Seq2[Token, error] {
+	l.setState(NewFileState(file))
+	return l.iterate()
+}
 
+func (l *Lexer) iterate() iter.Seq2[Token, error] {
 	return func(yield func(Token, error) bool) {
+		if l.err != nil {
+			yield(Token{}, l.err)
+			return
+		}
+
 		for !l.ReachedEOF() {
 			if !yield(l.NextToken()) {
 				return
@@ -214,100 +281,120 @@ func (l *Lexer) SkipWhitespace() {
 	}
 }
 
-// Lookahead returns the token at count offset from the cursor without consuming it
-func (l *Lexer) Lookahead(offset int) Token {
-	if l.state.LookaheadCache.ItemCount() >= offset {
-		return l.state.LookaheadCache.GetItem(offset - 1)
+func (l *Lexer) NextToken() (Token, error) {
+	token, err := l.nextToken()
+
+	if l.DebugPrintTokens {
+		token.Dump()
 	}
 
-	state := l.GetState()
-	var token Token
+	return token, err
+}
 
-	var i int
-	for i = 0; i < offset; i++ {
-		token, _ = l.nextToken()
-		state.LookaheadCache.AddItem(token)
+func (l *Lexer) nextToken() (Token, error) {
+	if l.err != nil {
+		return Token{}, l.err
+	}
 
-		if token.TypeIs(TypeEof) {
-			l.SetState(state)
-			return token
-		}
+	// Consume the tokens that were scanned ahead first
+	if entry, ok := l.state.Lookahead.pop(); ok {
+		l.restoreScanState(entry.after)
+		return entry.token, entry.err
 	}
 
-	l.SetState(state)
-	return token
+	l.state.Lookahead.skip()
+	return l.scanNextToken()
 }
 
-// LookaheadIterator returns an iterator that iterates over
-// the count number of tokens without consuming them
-func (l *Lexer) LookaheadIterator(count int) iter.Seq[Token] {
-	l.Lookahead(count)
-
-	return func(yield func(Token) bool) {
-		for i := 0; i < count; i++ {
-			if !yield(l.state.LookaheadCache.tokens[i]) {
-				return
-			}
+// scanNextToken scans the next token which is not ignored
+func (l *Lexer) scanNextToken() (Token, error) {
+	for {
+		token, err := l.scanToken()
+		if err != nil || !l.isIgnored(token) {
+			return token, err
 		}
-
-		return
 	}
 }
 
-func (l *Lexer) NextToken() (Token, error) {
-	token, err := l.nextToken()
+// isIgnored checks if the token should be skipped based on the lexer options
+func (l *Lexer) isIgnored(token Token) bool {
+	if token.TypeIs(TypeEof) {
+		return false
+	}
 
-	if l.DebugPrintTokens {
-		token.Dump()
+	if l.IgnoreComments && token.TypeIs(TypeComment) {
+		return true
 	}
 
-	return token, err
+	return token.TypeIsAnyOf(l.IgnoreTokens...)
 }
 
-func (l *Lexer) nextToken() (Token, error) {
-	// check if we have anything in the lookahead cache
-	if l.state.LookaheadCache.ContainsItems() {
-		return l.state.LookaheadCache.PluckItem(), nil
-	}
-
+// scanToken tokenizes the token at the cursor
+func (l *Lexer) scanToken() (Token, error) {
 	if l.IgnoreWhitespace {
 		l.SkipWhitespace()
 	}
 
 	if l.CursorIsOutOfBounds() {
+		position := l.GetPosition()
 		l.state.CurrentToken = &Token{
 			Type:     TypeEof,
 			Literal:  string(EOF),
-			Position: l.GetPosition(),
+			Position: position,
+			End:      position,
+			Pos:      l.pos(position),
 		}
 
 		return *l.state.CurrentToken, nil
 	}
 
 	var err error
+	start := l.state.Cursor
 	token := Token{
 		Type:     TypeInvalid,
 		Literal:  string(l.CharAtCursor()),
 		Position: l.GetPosition(),
 	}
 
-	for _, tokenizerType := range l.tokenizationOrder {
-		tokenizer, ok := l.tokenizers[tokenizerType]
-		if !ok {
-			continue
-		}
+	if length := l.NewlineLengthAtCursor(); length > 0 && !l.IgnoreWhitespace {
+		// Line terminators are a single newline token, also when they span multiple characters
+		token.Type = TypeNewline
+		token.Literal = string(l.contentBetween(l.state.Cursor, l.state.Cursor+length))
+		l.IncrementCursor(length - 1)
+	} else {
+		for _, tokenizerType := range l.tokenizationOrder {
+			tokenizer, ok := l.tokenizers[tokenizerType]
+			if !ok {
+				continue
+			}
 
-		if tokenizer.CanTokenize(l) {
-			token, err = l.tokenizers[tokenizerType].Tokenize(l)
-			break
+			if tokenizer.CanTokenize(l) {
+				token, err = l.tokenizers[tokenizerType].Tokenize(l)
+				break
+			}
 		}
 	}
 
+	// Tokenizers leave the cursor on the last character of the token,
+	// unterminated strings and comments leave it at the end of the content
+	if !l.CursorIsOutOfBounds() {
+		l.IncrementCursor(1)
+	}
+	token.End = l.GetPosition()
+	token.Pos = l.pos(token.Position)
 	l.state.CurrentToken = &token
-	l.IncrementCursor(1)
+
+	if e := l.invalidEncodingError(start, l.state.Cursor); e != nil && err == nil {
+		err = e
+	}
 
 	if token.TypeIs(TypeInvalid) && err == nil {
-		err = NewError(fmt.Sprintf("Invalid character '%c'", l.CharAtCursor()), token.Position, l.state.Content)
+		err = l.ErrorAt(ErrInvalidCharacter, fmt.Sprintf("Invalid character '%s'", token.Literal), token.Position)
+	}
+
+	// Errors at the start of the token span the scanned token
+	if e, ok := err.(*Error); ok && e.End == (Position{}) && e.Position == token.Position {
+		e.End = token.End
 	}
 
 	return token, err
@@ -315,7 +402,7 @@ func (l *Lexer) nextToken() (Token, error) {
 
 func (l *Lexer) GetPosition() Position {
 	if l.OmitTokenPosition {
-		return Position{}
+		return Position{Filename: l.state.Position.Filename}
 	}
 
 	if l.state.Cursor == l.state.Position.Cursor {
@@ -324,46 +411,103 @@ func (l *Lexer) GetPosition() Position {
 
 	l.state.Position.Cursor = l.state.Cursor
 
-	if l.state.LineIndexesCount == 0 {
-		l.state.Position.Col += l.state.Cursor - l.state.PositionCursor
-		l.state.PositionCursor = l.state.Cursor
-		return l.state.Position
+	if l.TrackByteOffsets {
+		l.state.Position.Offset += utf8Length(l.contentBetween(l.state.PositionCursor, l.state.Cursor))
 	}
 
-	nextLineStart := l.state.LineIndexes[0]
-	if l.state.Cursor <= nextLineStart {
-		l.state.Position.Col += l.state.Cursor - l.state.PositionCursor
-		l.state.PositionCursor = l.state.Cursor
-		return l.state.Position
+	// Move to the line of the cursor, tokens like comments and strings can span multiple lines
+	if row := l.state.Lines.LineOf(l.state.Cursor); row != l.state.Position.Row {
+		l.state.Position.Row = row
+		l.state.Position.Col = 1
+		l.state.PositionCursor = l.state.Lines.LineStart(row)
+
+		if l.TrackUTF16Columns {
+			l.state.Position.UTF16Col = 1
+		}
+
+		if l.TrackVisualColumns {
+			l.state.Position.VisualCol = 1
+		}
 	}
 
-	l.state.Position.Row += 1
-	l.state.Position.Col = l.state.Cursor - nextLineStart
-	l.state.PositionCursor = l.state.Cursor
+	l.state.Position.Col += l.state.Cursor - l.state.PositionCursor
 
-	// Remove the consumed entry
-	l.state.LineIndexes = l.state.LineIndexes[1:]
-	l.state.LineIndexesCount -= 1
+	if l.TrackUTF16Columns {
+		l.state.Position.UTF16Col += utf16Length(l.contentBetween(l.state.PositionCursor, l.state.Cursor))
+	}
+
+	if l.TrackVisualColumns {
+		l.state.Position.VisualCol += runesWidth(l.contentBetween(l.state.PositionCursor, l.state.Cursor), l.state.Position.VisualCol, l.TabWidth)
+	}
+
+	l.state.PositionCursor = l.state.Cursor
 
 	return l.state.Position
 }
 
+// invalidEncodingError returns an ErrInvalidEncoding error for the
+// first invalid encoded character between the offsets, or nil
+func (l *Lexer) invalidEncodingError(start int, end int) *Error {
+	i, _ := slices.BinarySearch(l.state.InvalidEncodings, start)
+	if i == len(l.state.InvalidEncodings) || l.state.InvalidEncodings[i] >= end {
+		return nil
+	}
+
+	position := l.state.Lines.PositionOf(l.state.InvalidEncodings[i])
+	position.Filename = l.state.Position.Filename
+
+	return l.ErrorAt(ErrInvalidEncoding, fmt.Sprintf("Invalid %s sequence", l.state.Encoding), position)
+}
+
+// newlines returns the recognised line terminators
+func (l *Lexer) newlines() Newlines {
+	if l.Newlines == 0 {
+		return NewlinesUnicode
+	}
+
+	return l.Newlines
+}
+
+// setState starts lexing a new source, initialising the optional position encodings
+func (l *Lexer) setState(state State) {
+	if l.TrackUTF16Columns {
+		state.Position.UTF16Col = 1
+	}
+
+	if l.TrackVisualColumns {
+		state.Position.VisualCol = 1
+	}
+
+	l.state = state
+}
+
+// pos returns the compact pos of the position when lexing a file
+func (l *Lexer) pos(position Position) Pos {
+	if l.state.File == nil || l.OmitTokenPosition {
+		return NoPos
+	}
+
+	return l.state.File.Pos(position.Cursor)
+}
+
+// GetCurrentLine returns the 1-based line number of the cursor and the offset of the start of that line
 func (l Lexer) GetCurrentLine() (int, int) {
-	// TODO: remove consumed lines
-	// TODO: this way we can always return the first entry
-
-	lastStart := 0
-	lastIndex := 0
-	for _, start := range l.state.LineIndexes {
-		if start >= l.state.Cursor {
-			return lastIndex, lastStart
-		}
+	line := l.state.Lines.LineOf(l.state.Cursor)
+	return line, l.state.Lines.LineStart(line)
+}
 
-		lastStart = start + 1
-		lastIndex += 1
+// NewlineLengthAtCursor returns the length of the line terminator at the cursor or 0 when there is none
+func (l *Lexer) NewlineLengthAtCursor() int {
+	if l.state.Lines == nil {
+		return NewlinesUnicode.Length(l.state.Content[:l.state.ContentLength], l.state.Cursor)
 	}
 
-	return len(l.state.LineIndexes), lastStart
+	return l.state.Lines.Newlines().Length(l.state.Content[:l.state.ContentLength], l.state.Cursor)
+}
+
+// Lines returns the line table of the source being lexed
+func (l *Lexer) Lines() *LineTable {
+	return l.state.Lines
 }
 
 // CharAtCursor returns the rune at the current cursor position
@@ -408,16 +552,29 @@ func (l *Lexer) NextCharsAre(chars []rune) bool {
 // Helpers / Getter/Setters
 // ---------------------------------------------------------------
 
+// contentBetween returns the content between the cursor positions, excluding the EOF sentinel
+func (l *Lexer) contentBetween(start int, end int) []rune {
+	end = min(end, l.state.ContentLength)
+	start = min(start, end)
+	return l.state.Content[start:end]
+}
+
 func (l *Lexer) GetSourceSubsString(start int, end int) string {
 	return string(l.state.Content[start:end])
 }
 
+// GetState returns a copy of the state, the copy does not share the lookahead buffer
 func (l *Lexer) GetState() State {
-	return l.state
+	state := l.state
+	state.Lookahead = l.state.Lookahead.clone()
+
+	return state
 }
 
+// SetState sets a copy of the state, the copy does not share the lookahead buffer
 func (l *Lexer) SetState(state State) {
 	l.state = state
+	l.state.Lookahead = state.Lookahead.clone()
 }
 
 func (l *Lexer) GetCursor() int {
@@ -1,46 +1,163 @@
 package golex
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 )
 
+// ErrorKind classifies lexer errors. The kinds are sentinel values
+// which can be matched using errors.Is on any *Error. The grammar packages
+// use ErrUndefined for their undefined productions, rules and nonterminals.
+type ErrorKind struct {
+	code        string
+	description string
+}
+
+func (k *ErrorKind) Error() string { return k.description }
+
+// Code returns the stable diagnostic code of the kind
+func (k *ErrorKind) Code() string { return k.code }
+
+var (
+	ErrInvalidCharacter    = &ErrorKind{code: "L0001", description: "invalid character"}
+	ErrUnterminatedString  = &ErrorKind{code: "L0002", description: "unterminated string"}
+	ErrUnterminatedComment = &ErrorKind{code: "L0003", description: "unterminated comment"}
+	ErrMalformedNumber     = &ErrorKind{code: "L0004", description: "malformed number"}
+	ErrInvalidEscape       = &ErrorKind{code: "L0005", description: "invalid escape sequence"}
+	ErrUnexpectedEOF       = &ErrorKind{code: "L0006", description: "unexpected end of file"}
+	ErrUnexpectedToken     = &ErrorKind{code: "L0007", description: "unexpected token"}
+	ErrInvalidEncoding     = &ErrorKind{code: "L0008", description: "invalid encoding"}
+	ErrUndefined           = &ErrorKind{code: "L0009", description: "undefined"}
+)
+
+// IsIncomplete checks if the error is caused by the input ending
+// prematurely, meaning that more input might resolve it
+func IsIncomplete(err error) bool {
+	return errors.Is(err, ErrUnterminatedString) ||
+		errors.Is(err, ErrUnterminatedComment) ||
+		errors.Is(err, ErrUnexpectedEOF)
+}
+
 // LexerError represents an error that occurred during lexical analysis.
 type Error struct {
+	Kind     *ErrorKind
 	Message  string
 	Position Position
-	Snippet  string
+	// End is the position after the erroneous input, a zero End marks the character at Position
+	End Position
+	// Snippet is the source line containing the error
+	Snippet string
+
+	source []rune
+	lines  *LineTable
 }
 
 // Error implements the error interface for LexerError
 func (e *Error) Error() string {
-	return fmt.Sprintf("%s: %s\n%s", e.Position.String(), e.Message, e.formatSnippet())
+	location := e.Position.String()
+	if e.Position.Filename != "" {
+		location = e.Position.Location()
+	}
+
+	snippet := e.formatSnippet()
+	if snippet == "" {
+		return fmt.Sprintf("%s: %s", location, e.Message)
+	}
+
+	return fmt.Sprintf("%s: %s\n%s", location, e.Message, snippet)
 }
 
-func NewError(message string, position Position, input []rune) *Error {
-	snippet := extractSnippet(input, position.Cursor)
+// Unwrap returns the kind of the error so it can be matched using errors.Is
+func (e *Error) Unwrap() error {
+	if e.Kind == nil {
+		return nil
+	}
+
+	return e.Kind
+}
+
+// ErrorAt creates a new error of the provided kind at the position in the source being lexed
+func (l *Lexer) ErrorAt(kind *ErrorKind, message string, position Position) *Error {
+	if l.state.Lines == nil {
+		return NewErrorOfKind(kind, message, position, l.state.Content)
+	}
+
+	return NewErrorInLines(kind, message, position, l.state.Lines)
+}
+
+// NewErrorInLines creates a new error of the provided kind at the position in the source of the line table.
+// Errors sharing the table of their source don't index the source again when they are rendered.
+func NewErrorInLines(kind *ErrorKind, message string, position Position, lines *LineTable) *Error {
 	return &Error{
+		Kind:     kind,
 		Message:  message,
 		Position: position,
-		Snippet:  snippet,
+		Snippet:  lines.LineText(position.Row),
+		source:   lines.source,
+		lines:    lines,
 	}
 }
 
-// Formats the snippet with a caret (^) to indicate the exact error location.
-func (e *Error) formatSnippet() string {
-	caretPosition := strings.Repeat(" ", e.Position.Col-1) + "^"
-	return fmt.Sprintf("    %s\n    %s", e.Snippet, caretPosition)
+// NewErrorOfKind creates a new error of the provided kind
+func NewErrorOfKind(kind *ErrorKind, message string, position Position, input []rune) *Error {
+	e := NewError(message, position, input)
+	e.Kind = kind
+	return e
 }
 
-func extractSnippet(input []rune, cursor int) string {
-	const contextLength = 20
-	start := cursor - contextLength
-	if start < 0 {
-		start = 0
+func NewError(message string, position Position, input []rune) *Error {
+	// The lexer content is terminated by the EOF sentinel which is not part of the source
+	if len(input) > 0 && input[len(input)-1] == EOF {
+		input = input[:len(input)-1]
+	}
+
+	e := &Error{
+		Message:  message,
+		Position: position,
+		source:   input,
+	}
+
+	// The line table of the diagnostic is only built when the error is rendered
+	if len(input) > 0 {
+		e.Snippet = lineText(input, NewlinesUnicode, position.Row)
+	}
+
+	return e
+}
+
+// Diagnostic returns the error as a diagnostic for rich rendering
+func (e *Error) Diagnostic() Diagnostic {
+	d := Diagnostic{
+		Severity: SeverityError,
+		Message:  e.Message,
+		Source:   e.source,
+		Lines:    e.lines,
 	}
-	end := cursor + contextLength
-	if end > len(input) {
-		end = len(input)
+
+	if e.Kind != nil {
+		d.Code = e.Kind.Code()
+	}
+
+	if e.Position.Row > 0 {
+		d.Labels = []Label{e.label()}
 	}
-	return string(input[start:end])
+
+	return d
+}
+
+func (e *Error) label() Label {
+	return PrimaryLabel(e.Position, e.End, "")
+}
+
+// Formats the source line with a caret (^) to indicate the exact error location.
+func (e *Error) formatSnippet() string {
+	d := e.Diagnostic()
+	if len(d.Labels) == 0 || len(d.Source) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	DiagnosticRenderer{}.writeSnippet(&sb, d, d.Labels, labelsGutterWidth(d.Labels))
+	return strings.TrimSuffix(sb.String(), "\n")
 }
@@ -0,0 +1,276 @@
+package golex
+
+import (
+	"errors"
+	"iter"
+	"maps"
+	"slices"
+)
+
+// ###################################################
+// #              Lookahead Buffer
+// ###################################################
+
+// scanState is the part of the state that changes while scanning tokens
+type scanState struct {
+	Cursor         int
+	PositionCursor int
+	Position       Position
+	CurrentToken   *Token
+}
+
+// lookaheadEntry is a scanned token with its error and the scan state directly after it
+type lookaheadEntry struct {
+	token Token
+	err   error
+	after scanState
+}
+
+// LookaheadBuffer is a ring buffer of the tokens scanned ahead of the lexer.
+// Entries are addressed by their absolute token index. Consumed entries
+// are retained until their slot is needed, so resetting to a recent mark
+// does not need to scan the tokens again.
+type LookaheadBuffer struct {
+	entries []lookaheadEntry
+	// start is the index of the oldest retained entry
+	start int
+	// head is the index of the next entry to consume
+	head int
+	// end is the index directly after the newest entry
+	end int
+}
+
+const defaultLookaheadCapacity = 8
+
+// Len returns the number of scanned tokens that are not consumed yet
+func (b *LookaheadBuffer) Len() int { return b.end - b.head }
+
+// Index returns the index of the next token to consume
+func (b *LookaheadBuffer) Index() int { return b.head }
+
+// at returns the entry at the absolute index
+func (b *LookaheadBuffer) at(index int) *lookaheadEntry {
+	return &b.entries[index%len(b.entries)]
+}
+
+// last returns the newest entry, if any
+func (b *LookaheadBuffer) last() (*lookaheadEntry, bool) {
+	if b.end == b.head {
+		return nil, false
+	}
+
+	return b.at(b.end - 1), true
+}
+
+func (b *LookaheadBuffer) push(entry lookaheadEntry) {
+	if b.end-b.start == len(b.entries) {
+		if b.head > b.start {
+			// Reuse the slot of the oldest consumed entry
+			b.start += 1
+		} else {
+			b.grow()
+		}
+	}
+
+	*b.at(b.end) = entry
+	b.end += 1
+}
+
+func (b *LookaheadBuffer) grow() {
+	entries := make([]lookaheadEntry, max(len(b.entries)*2, defaultLookaheadCapacity))
+	for i := b.start; i < b.end; i++ {
+		entries[i%len(entries)] = *b.at(i)
+	}
+
+	b.entries = entries
+}
+
+func (b *LookaheadBuffer) pop() (lookaheadEntry, bool) {
+	if b.head == b.end {
+		return lookaheadEntry{}, false
+	}
+
+	entry := *b.at(b.head)
+	b.head += 1
+
+	return entry, true
+}
+
+// clone returns a copy of the buffer which does not share its entries
+func (b LookaheadBuffer) clone() LookaheadBuffer {
+	b.entries = slices.Clone(b.entries)
+	return b
+}
+
+// skip advances the index past a token that was consumed without being buffered
+func (b *LookaheadBuffer) skip() {
+	b.head += 1
+	b.start = b.head
+	b.end = b.head
+}
+
+// rewind moves the index back to a retained entry, it reports false when the entry is no longer retained
+func (b *LookaheadBuffer) rewind(index int) bool {
+	if index < b.start || index > b.end {
+		return false
+	}
+
+	b.head = index
+	return true
+}
+
+// clear drops all entries and continues at the index
+func (b *LookaheadBuffer) clear(index int) {
+	b.start = index
+	b.head = index
+	b.end = index
+}
+
+// ###################################################
+// #              Lookahead & Backtracking
+// ###################################################
+
+// Mark is a checkpoint of the lexer created by Lexer.Mark
+type Mark struct {
+	index int
+	scan  scanState
+}
+
+// Mark returns a checkpoint of the current position which can be returned to using Reset
+func (l *Lexer) Mark() Mark {
+	return Mark{index: l.state.Lookahead.Index(), scan: l.scanState()}
+}
+
+// Reset returns the lexer to the checkpoint. Tokens which are still buffered
+// are reused, tokens further back are scanned again.
+func (l *Lexer) Reset(mark Mark) {
+	if !l.state.Lookahead.rewind(mark.index) {
+		l.state.Lookahead.clear(mark.index)
+	}
+
+	l.restoreScanState(mark.scan)
+}
+
+// Peek returns the token at the offset from the cursor without consuming it, the next token is at offset 1
+// and the last consumed token at offset 0. Peeking past the end of the source returns the EOF token.
+func (l *Lexer) Peek(offset int) Token {
+	if offset <= 0 {
+		return *l.state.CurrentToken
+	}
+
+	l.fillLookahead(offset)
+
+	if offset > l.state.Lookahead.Len() {
+		entry, _ := l.state.Lookahead.last()
+		return entry.token
+	}
+
+	return l.state.Lookahead.at(l.state.Lookahead.Index() + offset - 1).token
+}
+
+// Lookahead returns the token at the offset from the cursor without consuming it.
+// It is an alias of Peek.
+func (l *Lexer) Lookahead(offset int) Token {
+	return l.Peek(offset)
+}
+
+// LookaheadIterator returns an iterator that iterates over
+// the count number of tokens without consuming them
+func (l *Lexer) LookaheadIterator(count int) iter.Seq[Token] {
+	l.fillLookahead(count)
+
+	return func(yield func(Token) bool) {
+		for i := 1; i <= count; i++ {
+			if !yield(l.Peek(i)) {
+				return
+			}
+		}
+	}
+}
+
+// fillLookahead scans tokens until the buffer holds count tokens or the EOF token
+func (l *Lexer) fillLookahead(count int) {
+	if l.state.Lookahead.Len() >= count {
+		return
+	}
+
+	current := l.scanState()
+	if entry, ok := l.state.Lookahead.last(); ok {
+		l.restoreScanState(entry.after)
+	}
+
+	for l.state.Lookahead.Len() < count {
+		if entry, ok := l.state.Lookahead.last(); ok && entry.token.TypeIs(TypeEof) {
+			break
+		}
+
+		token, err := l.scanNextToken()
+		l.state.Lookahead.push(lookaheadEntry{token: token, err: err, after: l.scanState()})
+	}
+
+	l.restoreScanState(current)
+}
+
+// ###################################################
+// #              Speculative Lexing
+// ###################################################
+
+// ErrNotFork is returned when joining a lexer that is not a fork of the lexer
+var ErrNotFork = errors.New("the joined lexer is not a fork of this lexer")
+
+// Fork returns an independent lexer at the current position of the source.
+// The fork shares the configuration and the read-only source data, but has its own cursor,
+// lookahead buffer and built-in tokenizers, so the lexer and the fork can be used concurrently.
+// Custom tokenizers added using WithTokenizer are shared and must be safe for concurrent use to do so.
+func (l *Lexer) Fork() *Lexer {
+	fork := *l
+	fork.state.Lookahead = l.state.Lookahead.clone()
+
+	// The built-in tokenizers are fields of their lexer
+	fork.tokenizers = maps.Clone(l.tokenizers)
+	builtin := map[Tokenizer]Tokenizer{
+		&l.CommentTokenizer: &fork.CommentTokenizer,
+		&l.LiteralTokenizer: &fork.LiteralTokenizer,
+		&l.NumberTokenizer:  &fork.NumberTokenizer,
+		&l.BooleanTokenizer: &fork.BooleanTokenizer,
+		&l.StringTokenizer:  &fork.StringTokenizer,
+		&l.SymbolTokenizer:  &fork.SymbolTokenizer,
+	}
+
+	for tokenizerType, tokenizer := range fork.tokenizers {
+		if own, ok := builtin[tokenizer]; ok {
+			fork.tokenizers[tokenizerType] = own
+		}
+	}
+
+	return &fork
+}
+
+// Join moves the lexer to the position of the fork, adopting its lookahead buffer.
+// It returns ErrNotFork when the fork is not lexing the same source.
+func (l *Lexer) Join(fork *Lexer) error {
+	if len(fork.state.Content) != len(l.state.Content) || len(l.state.Content) == 0 || &fork.state.Content[0] != &l.state.Content[0] {
+		return ErrNotFork
+	}
+
+	l.restoreScanState(fork.scanState())
+	l.state.Lookahead = fork.state.Lookahead.clone()
+
+	return nil
+}
+
+func (l *Lexer) scanState() scanState {
+	return scanState{
+		Cursor:         l.state.Cursor,
+		PositionCursor: l.state.PositionCursor,
+		Position:       l.state.Position,
+		CurrentToken:   l.state.CurrentToken,
+	}
+}
+
+func (l *Lexer) restoreScanState(s scanState) {
+	l.state.Cursor = s.Cursor
+	l.state.PositionCursor = s.PositionCursor
+	l.state.Position = s.Position
+	l.state.CurrentToken = s.CurrentToken
+}
@@ -16,18 +16,65 @@ func OmitTokenPosition() LexerOptionFunc {
 	})
 }
 
+// TrackByteOffsets makes the lexer report the UTF-8 byte offset of every position
+func TrackByteOffsets() LexerOptionFunc {
+	return LexerOptionFunc(func(l *Lexer) {
+		l.TrackByteOffsets = true
+	})
+}
+
+// TrackUTF16Columns makes the lexer report the UTF-16 code unit column of every position
+func TrackUTF16Columns() LexerOptionFunc {
+	return LexerOptionFunc(func(l *Lexer) {
+		l.TrackUTF16Columns = true
+	})
+}
+
+// TrackVisualColumns makes the lexer report the displayed column (Position.VisualCol) of every position,
+// expanding tabs to the tab width and counting wide East Asian characters as two columns
+func TrackVisualColumns(tabWidth int) LexerOptionFunc {
+	return LexerOptionFunc(func(l *Lexer) {
+		l.TrackVisualColumns = true
+		l.TabWidth = tabWidth
+	})
+}
+
 func IgnoreTokens(types ...TokenType) LexerOptionFunc {
 	return LexerOptionFunc(func(l *Lexer) {
 		l.IgnoreTokens = append(l.IgnoreTokens, types...)
 	})
 }
 
+// WithNewlines sets the line terminators the lexer recognises for positions,
+// newline tokens and the end of single line comments. Files of a FileSet
+// recognise the line terminators they were added with, see FileSet.AddFileWith.
+func WithNewlines(newlines Newlines) LexerOptionFunc {
+	return LexerOptionFunc(func(l *Lexer) {
+		l.Newlines = newlines
+	})
+}
+
 func RetainWhitespace() LexerOptionFunc {
 	return LexerOptionFunc(func(l *Lexer) {
 		l.IgnoreWhitespace = false
 	})
 }
 
+// IgnoreComments makes the lexer skip comment tokens
+func IgnoreComments() LexerOptionFunc {
+	return LexerOptionFunc(func(l *Lexer) {
+		l.IgnoreComments = true
+	})
+}
+
+// ValidateEscapeSequences makes escapable strings return an
+// ErrInvalidEscape error for unknown escape sequences
+func ValidateEscapeSequences() LexerOptionFunc {
+	return LexerOptionFunc(func(l *Lexer) {
+		l.ValidateEscapeSequences = true
+	})
+}
+
 func WithKeywords(keywords ...string) LexerOptionFunc {
 	return LexerOptionFunc(func(l *Lexer) {
 		l.Keywords = append(l.Keywords, keywords...)
@@ -62,8 +109,18 @@ func WithTokenizer(inserter TokenizerInserter) LexerOptionFunc {
 	})
 }
 
+// WithLiteralTokens extends the literal tokens. The token types and their declared metadata are
+// registered in the token type registry, a name already taken by another type is reported by Lexer.Err.
 func WithLiteralTokens(literalTokens ...LiteralToken) LexerOptionFunc {
 	return LexerOptionFunc(func(l *Lexer) {
+		for _, t := range literalTokens {
+			if t.Info != (TokenTypeInfo{}) {
+				l.optionError(RegisterTokenTypeInfo(t.Type, t.Info))
+			} else {
+				l.optionError(RegisterTokenType(t.Type))
+			}
+		}
+
 		l.LiteralTokens = SortLiteralTokens(append(l.LiteralTokens, literalTokens...))
 	})
 }
@@ -102,8 +159,14 @@ func WithoutCommentSyntax(syntaxes ...CommentSyntax) LexerOptionFunc {
 	})
 }
 
+// WithStringEnclosure adds string enclosures. The token types are registered in
+// the token type registry, a name already taken by another type is reported by Lexer.Err.
 func WithStringEnclosure(enclosures ...StringEnclosure) LexerOptionFunc {
 	return LexerOptionFunc(func(l *Lexer) {
+		for _, e := range enclosures {
+			l.optionError(RegisterTokenType(e.Type))
+		}
+
 		l.StringEnclosures = append(l.StringEnclosures, enclosures...)
 	})
 }
@@ -121,7 +121,7 @@ func (l *Lexer) IterateTokensDelimited(tokenType TokenType, delimiter TokenType)
 	return func(yield func(Token, error) bool) {
 		for !token.TypeIs(TypeEof) {
 			if !token.TypeIs(tokenType) {
-				yield(token, fmt.Errorf("expected %s but found %s", tokenType, token.Type))
+				yield(token, l.ErrorAt(ErrUnexpectedToken, fmt.Sprintf("expected %s but found %s", tokenType, token.Type), token.Position))
 			}
 
 			if !yield(token, nil) {
@@ -235,7 +235,7 @@ func (l *Lexer) CollectTokensBetween(open TokenType, close TokenType) (Tokens, i
 		}
 
 		if token.TypeIs(TypeEof) {
-			return tokens, start, end, fmt.Errorf("Unexpected EndOfFile")
+			return tokens, start, end, l.ErrorAt(ErrUnexpectedEOF, "Unexpected EndOfFile", token.Position)
 		}
 
 		if token.TypeIs(close) {
@@ -263,7 +263,7 @@ func (l *Lexer) CollectTokensDelimited(tokenType TokenType, delimiter TokenType)
 	token := l.CurrentToken()
 	for !token.TypeIs(TypeEof) {
 		if !token.TypeIs(tokenType) {
-			return tokens, fmt.Errorf("expected %s but found %s", tokenType, token.Type)
+			return tokens, l.ErrorAt(ErrUnexpectedToken, fmt.Sprintf("expected %s but found %s", tokenType, token.Type), token.Position)
 		}
 
 		tokens = append(tokens, token)
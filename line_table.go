@@ -0,0 +1,197 @@
+package golex
+
+import "sort"
+
+// Newlines is a set of recognised line terminators
+type Newlines uint8
+
+const (
+	// NewlineLF is "\n"
+	NewlineLF Newlines = 1 << iota
+	// NewlineCRLF is "\r\n", recognised as a single line terminator
+	NewlineCRLF
+	// NewlineCR is a lone "\r"
+	NewlineCR
+	// NewlineNEL is the next line character (U+0085)
+	NewlineNEL
+	// NewlineLS is the Unicode line separator (U+2028)
+	NewlineLS
+	// NewlinePS is the Unicode paragraph separator (U+2029)
+	NewlinePS
+
+	// NewlinesUnix only recognises "\n"
+	NewlinesUnix = NewlineLF
+	// NewlinesASCII recognises "\n", "\r\n" and a lone "\r"
+	NewlinesASCII = NewlineLF | NewlineCRLF | NewlineCR
+	// NewlinesUnicode recognises all line terminators, it is the default
+	NewlinesUnicode = NewlinesASCII | NewlineNEL | NewlineLS | NewlinePS
+)
+
+// Length returns the length of the line terminator at the offset or 0 when there is none
+func (n Newlines) Length(source []rune, offset int) int {
+	if offset < 0 || offset >= len(source) {
+		return 0
+	}
+
+	var newline Newlines
+	switch source[offset] {
+	case '\r':
+		if n&NewlineCRLF != 0 && offset+1 < len(source) && source[offset+1] == '\n' {
+			return 2
+		}
+
+		newline = NewlineCR
+	case '\n':
+		newline = NewlineLF
+	case '\u0085':
+		newline = NewlineNEL
+	case '\u2028':
+		newline = NewlineLS
+	case '\u2029':
+		newline = NewlinePS
+	}
+
+	if n&newline != 0 {
+		return 1
+	}
+
+	return 0
+}
+
+// LineTable is a persistent index of the lines of a source.
+// By default it recognises "\n", "\r\n", a lone "\r", NEL (U+0085) and the Unicode
+// line and paragraph separators (U+2028, U+2029) as line terminators.
+// All offsets are cursor offsets, counted in runes, and all line numbers are 1-based.
+type LineTable struct {
+	source   []rune
+	newlines Newlines
+	// starts holds the offset of the first character of every line
+	starts []int
+	// ends holds the offset of the line terminator of every line
+	ends []int
+}
+
+// NewLineTable indexes the lines of the source, recognising all line terminators
+func NewLineTable(source []rune) *LineTable {
+	return NewLineTableWith(source, NewlinesUnicode)
+}
+
+// NewLineTableWith indexes the lines of the source, only recognising the provided line terminators
+func NewLineTableWith(source []rune, newlines Newlines) *LineTable {
+	t := &LineTable{source: source, newlines: newlines, starts: []int{0}}
+
+	for i := 0; i < len(source); i++ {
+		length := newlines.Length(source, i)
+		if length == 0 {
+			continue
+		}
+
+		t.ends = append(t.ends, i)
+		i += length - 1
+		t.starts = append(t.starts, i+1)
+	}
+
+	t.ends = append(t.ends, len(source))
+
+	return t
+}
+
+// Newlines returns the line terminators recognised by the table
+func (t *LineTable) Newlines() Newlines {
+	return t.newlines
+}
+
+// LineCount returns the number of lines
+func (t *LineTable) LineCount() int {
+	return len(t.starts)
+}
+
+// LineOf returns the line containing the offset.
+// Line terminators belong to the line they terminate.
+func (t *LineTable) LineOf(offset int) int {
+	offset = min(max(offset, 0), len(t.source))
+
+	// The number of line starts before or at the offset is the line
+	return sort.SearchInts(t.starts, offset+1)
+}
+
+// LineStart returns the offset of the first character of the line
+func (t *LineTable) LineStart(line int) int {
+	if line < 1 {
+		return 0
+	}
+
+	if line > len(t.starts) {
+		return len(t.source)
+	}
+
+	return t.starts[line-1]
+}
+
+// LineEnd returns the offset of the line terminator of the line,
+// or the end of the source for the last line
+func (t *LineTable) LineEnd(line int) int {
+	if line < 1 {
+		return 0
+	}
+
+	if line > len(t.ends) {
+		return len(t.source)
+	}
+
+	return t.ends[line-1]
+}
+
+// LineText returns the text of the line without its line terminator
+func (t *LineTable) LineText(line int) string {
+	return string(t.lineRunes(line))
+}
+
+func (t *LineTable) lineRunes(line int) []rune {
+	if line < 1 || line > len(t.starts) {
+		return nil
+	}
+
+	return t.source[t.starts[line-1]:t.ends[line-1]]
+}
+
+// lineText returns the text of the line without its line terminator, only scanning the source up to the line
+func lineText(source []rune, newlines Newlines, line int) string {
+	row, start := 1, 0
+
+	for i := 0; i < len(source) && row < line; i++ {
+		if length := newlines.Length(source, i); length > 0 {
+			row, start = row+1, i+length
+			i += length - 1
+		}
+	}
+
+	if row != line {
+		return ""
+	}
+
+	end := start
+	for end < len(source) && newlines.Length(source, end) == 0 {
+		end++
+	}
+
+	return string(source[start:end])
+}
+
+// PositionOf returns the position of the offset
+func (t *LineTable) PositionOf(offset int) Position {
+	offset = min(max(offset, 0), len(t.source))
+	line := t.LineOf(offset)
+
+	return Position{Row: line, Col: offset - t.LineStart(line) + 1, Cursor: offset}
+}
+
+// OffsetOf returns the offset of the 1-based row and column
+func (t *LineTable) OffsetOf(row int, col int) int {
+	return min(t.LineStart(row)+max(col-1, 0), len(t.source))
+}
+
+// SpanOf returns the span between the offsets
+func (t *LineTable) SpanOf(start int, end int) Span {
+	return Span{Start: t.PositionOf(start), End: t.PositionOf(end)}
+}
@@ -0,0 +1,275 @@
+// Package ll1 builds LL(1) predictive parse tables for grammars over golex tokens.
+//
+// A Grammar is a list of BNF productions whose terminals are golex token types
+// or literals. Build computes the FIRST and FOLLOW sets, fills the predictive
+// table and reports every conflict, which makes it possible to check that a
+// grammar stays LL(1) as it evolves. The table drives a parser which consumes
+// the tokens of a golex Lexer with a single token of lookahead.
+//
+// A literal terminal is also a token of the type it is lexed as, so a production
+// starting with "x" conflicts with a production starting with Symbol. Build finds
+// the types of the literals by lexing them, see Grammar.LexWith for keywords.
+//
+// Grammars written in EBNF can be converted using FromEBNF.
+package ll1
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cornejong/golex"
+	"github.com/cornejong/golex/ebnf"
+)
+
+// ErrUndefinedNonterminal is golex.ErrUndefined, returned when a production refers to a nonterminal without productions
+var ErrUndefinedNonterminal = golex.ErrUndefined
+
+// Symbol is a nonterminal, a token type or a literal
+type Symbol struct {
+	// Name is the name of a nonterminal, it is empty for terminals
+	Name string
+	// Type is the token type of a terminal
+	Type golex.TokenType
+	// Literal is the literal of a terminal, matching a token of any type
+	Literal string
+}
+
+// Nonterminal returns the symbol of the nonterminal
+func Nonterminal(name string) Symbol { return Symbol{Name: name} }
+
+// Terminal returns the symbol of tokens of the token type
+func Terminal(tokenType golex.TokenType) Symbol { return Symbol{Type: tokenType} }
+
+// Literal returns the symbol of tokens with the literal
+func Literal(literal string) Symbol { return Symbol{Type: golex.AnyTokenType, Literal: literal} }
+
+// IsTerminal reports whether the symbol is a token type or literal
+func (s Symbol) IsTerminal() bool { return s.Name == "" }
+
+// Pattern returns the token pattern of a terminal, matching tokens using Token.Is
+func (s Symbol) Pattern() golex.Token { return golex.Token{Type: s.Type, Literal: s.Literal} }
+
+// Matches reports whether the token matches the terminal
+func (s Symbol) Matches(token golex.Token) bool { return token.Is(s.Pattern()) }
+
+// String returns the name of nonterminals and token types, and the quoted literal of literals
+func (s Symbol) String() string {
+	switch {
+	case s.Name != "":
+		return s.Name
+	case s.Literal != "":
+		return strconv.Quote(s.Literal)
+	case s.Type != nil:
+		return s.Type.String()
+	}
+
+	return "<invalid>"
+}
+
+// Production is a rule rewriting the nonterminal to the symbols, an empty list of symbols is ε
+type Production struct {
+	Name    string
+	Symbols []Symbol
+}
+
+func (p Production) String() string {
+	if len(p.Symbols) == 0 {
+		return p.Name + " → ε"
+	}
+
+	symbols := make([]string, len(p.Symbols))
+	for i, symbol := range p.Symbols {
+		symbols[i] = symbol.String()
+	}
+
+	return p.Name + " → " + strings.Join(symbols, " ")
+}
+
+// Grammar is a context-free grammar in BNF
+type Grammar struct {
+	Start       string
+	Productions []Production
+
+	inline map[string]bool
+	// lexerOptions configure the lexer which finds the token types of the literals
+	lexerOptions []golex.LexerOptionFunc
+}
+
+// NewGrammar creates an empty grammar with the start nonterminal
+func NewGrammar(start string) *Grammar {
+	return &Grammar{Start: start, inline: map[string]bool{}}
+}
+
+// Add adds a production of the nonterminal, without symbols it is an ε production
+func (g *Grammar) Add(name string, symbols ...Symbol) *Grammar {
+	g.Productions = append(g.Productions, Production{Name: name, Symbols: symbols})
+	return g
+}
+
+// Inline marks helper nonterminals whose nodes are replaced by their children in the parse tree
+func (g *Grammar) Inline(names ...string) *Grammar {
+	if g.inline == nil {
+		g.inline = map[string]bool{}
+	}
+
+	for _, name := range names {
+		g.inline[name] = true
+	}
+
+	return g
+}
+
+// LexWith sets the options of the lexer the literals are lexed with to find the token types
+// they overlap. By default a keyword like "let" is a Symbol, lexing it with
+// golex.WithKeywords("let") makes it a Keyword which does not conflict with Symbol.
+func (g *Grammar) LexWith(options ...golex.LexerOptionFunc) *Grammar {
+	g.lexerOptions = options
+	return g
+}
+
+// Nonterminals returns the nonterminals in the order of their first production
+func (g *Grammar) Nonterminals() []string {
+	names := []string{}
+	seen := map[string]bool{}
+
+	for _, production := range g.Productions {
+		if !seen[production.Name] {
+			seen[production.Name] = true
+			names = append(names, production.Name)
+		}
+	}
+
+	return names
+}
+
+// verify checks that the start symbol and every nonterminal used in a production has productions
+func (g *Grammar) verify() error {
+	defined := map[string]bool{}
+	for _, production := range g.Productions {
+		defined[production.Name] = true
+	}
+
+	if !defined[g.Start] {
+		return fmt.Errorf("%w nonterminal %s", ErrUndefinedNonterminal, g.Start)
+	}
+
+	for _, production := range g.Productions {
+		for _, symbol := range production.Symbols {
+			if !symbol.IsTerminal() && !defined[symbol.Name] {
+				return fmt.Errorf("%w nonterminal %s in %s", ErrUndefinedNonterminal, symbol.Name, production)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ###################################################
+// #              EBNF
+// ###################################################
+
+// FromEBNF converts the verified EBNF grammar to BNF. Groups with alternatives, options and
+// repetitions become helper nonterminals named after their production, like "Arguments.1",
+// which are inlined in the parse tree so it has the same shape as the tree of ebnf.Grammar.Parse.
+func FromEBNF(grammar *ebnf.Grammar, start string) (*Grammar, error) {
+	if err := grammar.Verify(start); err != nil {
+		return nil, err
+	}
+
+	c := &converter{source: grammar, grammar: NewGrammar(start), helpers: map[string]int{}}
+	for _, name := range grammar.Names {
+		for _, alternative := range alternatives(grammar.Productions[name].Expr) {
+			c.grammar.Add(name, c.symbols(name, alternative)...)
+		}
+
+		// The productions of the helpers follow the production they were created for
+		c.grammar.Productions = append(c.grammar.Productions, c.pending...)
+		c.pending = nil
+	}
+
+	return c.grammar, nil
+}
+
+type converter struct {
+	source  *ebnf.Grammar
+	grammar *Grammar
+	helpers map[string]int
+	pending []Production
+}
+
+func (c *converter) add(name string, symbols ...Symbol) {
+	c.pending = append(c.pending, Production{Name: name, Symbols: symbols})
+}
+
+func alternatives(expr ebnf.Expression) []ebnf.Expression {
+	if alternative, ok := expr.(ebnf.Alternative); ok {
+		return alternative
+	}
+
+	return []ebnf.Expression{expr}
+}
+
+// helper creates a new inlined nonterminal for the production
+func (c *converter) helper(production string) string {
+	c.helpers[production] += 1
+	name := fmt.Sprintf("%s.%d", production, c.helpers[production])
+	c.grammar.Inline(name)
+
+	return name
+}
+
+func (c *converter) symbols(production string, expr ebnf.Expression) []Symbol {
+	switch x := expr.(type) {
+	case ebnf.Sequence:
+		symbols := []Symbol{}
+		for _, e := range x {
+			symbols = append(symbols, c.symbols(production, e)...)
+		}
+
+		return symbols
+
+	case *ebnf.Name:
+		if _, ok := c.source.Productions[x.String]; ok {
+			return []Symbol{Nonterminal(x.String)}
+		}
+
+		tokenType, _ := golex.LookupTokenType(x.String)
+		return []Symbol{Terminal(tokenType)}
+
+	case *ebnf.Token:
+		return []Symbol{Literal(x.String)}
+
+	case *ebnf.Group:
+		if _, ok := x.Body.(ebnf.Alternative); !ok {
+			return c.symbols(production, x.Body)
+		}
+
+		name := c.helper(production)
+		for _, alternative := range alternatives(x.Body) {
+			c.add(name, c.symbols(production, alternative)...)
+		}
+
+		return []Symbol{Nonterminal(name)}
+
+	case *ebnf.Option:
+		name := c.helper(production)
+		for _, alternative := range alternatives(x.Body) {
+			c.add(name, c.symbols(production, alternative)...)
+		}
+
+		c.add(name)
+		return []Symbol{Nonterminal(name)}
+
+	case *ebnf.Repetition:
+		name := c.helper(production)
+		for _, alternative := range alternatives(x.Body) {
+			c.add(name, append(c.symbols(production, alternative), Nonterminal(name))...)
+		}
+
+		c.add(name)
+		return []Symbol{Nonterminal(name)}
+	}
+
+	return nil
+}
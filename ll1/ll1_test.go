@@ -0,0 +1,250 @@
+package ll1
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cornejong/golex"
+	"github.com/cornejong/golex/ast"
+	"github.com/cornejong/golex/ebnf"
+	"github.com/cornejong/golex/internal/golextest"
+)
+
+func getExpressionGrammar() *Grammar {
+	return NewGrammar("Expr").
+		Add("Expr", Nonterminal("Term"), Nonterminal("Expr'")).
+		Add("Expr'", Terminal(golex.TypePlus), Nonterminal("Term"), Nonterminal("Expr'")).
+		Add("Expr'").
+		Add("Term", Nonterminal("Factor"), Nonterminal("Term'")).
+		Add("Term'", Terminal(golex.TypeMultiply), Nonterminal("Factor"), Nonterminal("Term'")).
+		Add("Term'").
+		Add("Factor", Literal("("), Nonterminal("Expr"), Literal(")")).
+		Add("Factor", Terminal(golex.TypeInteger)).
+		Inline("Expr'", "Term'")
+}
+
+func getLexer(source string, options ...golex.LexerOptionFunc) *golex.Lexer {
+	lexer := golex.NewLexer(options...)
+	lexer.TokenizeManual(source)
+
+	return lexer
+}
+
+func TestTableSets(t *testing.T) {
+	fmt.Println("TestTableSets...")
+
+	table, err := Build(getExpressionGrammar())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !table.IsLL1() {
+		t.Fatalf("Expected the expression grammar to be LL(1) but got %v", table.Err())
+	}
+
+	differ := &golex.Differ{}
+	for name, expected := range map[string][2]string{
+		"Expr":   {`{"(", Integer}`, `{")", EndOfFile}`},
+		"Expr'":  {`{Plus}`, `{")", EndOfFile}`},
+		"Term":   {`{"(", Integer}`, `{")", EndOfFile, Plus}`},
+		"Term'":  {`{Multiply}`, `{")", EndOfFile, Plus}`},
+		"Factor": {`{"(", Integer}`, `{")", EndOfFile, Multiply, Plus}`},
+	} {
+		differ.Compare(expected, [2]string{table.First[name].String(), table.Follow[name].String()})
+		if differ.HasDifference() {
+			fmt.Println(name, differ)
+			t.FailNow()
+		}
+	}
+
+	if !table.Nullable["Expr'"] || table.Nullable["Expr"] {
+		t.Errorf("Expected only Expr' and Term' to be nullable")
+	}
+
+	if predicted := table.Predict("Term'", golex.Token{Type: golex.TypePlus}); len(predicted) != 1 || predicted[0].String() != "Term' → ε" {
+		t.Errorf("Expected Term' → ε to be predicted on Plus but got %v", predicted)
+	}
+}
+
+func TestTableConflicts(t *testing.T) {
+	fmt.Println("TestTableConflicts...")
+
+	g := NewGrammar("Statement").
+		Add("Statement", Terminal(golex.TypeSymbol), Literal("="), Nonterminal("Value")).
+		Add("Statement", Terminal(golex.TypeSymbol), Literal("("), Literal(")")).
+		Add("Value", Literal("["), Nonterminal("Items"), Literal("]")).
+		Add("Items", Terminal(golex.TypeInteger), Nonterminal("Rest")).
+		Add("Rest", Literal("]"), Literal("]")).
+		Add("Rest")
+
+	table, err := Build(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	differ := &golex.Differ{}
+	differ.Compare(
+		"LL(1) conflict: Statement on Symbol predicts Statement → Symbol \"=\" Value and Statement → Symbol \"(\" \")\"\n"+
+			"LL(1) conflict: Rest on \"]\" predicts Rest → \"]\" \"]\" and Rest → ε",
+		table.Err().Error(),
+	)
+
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+
+	if !errors.Is(table.Err(), ErrConflict) || len(table.Conflicts) != 2 || table.Conflicts[1].Lookahead != Literal("]") {
+		t.Errorf("Expected 2 conflicts but got %v", table.Conflicts)
+	}
+
+	// Left recursion is a FIRST/FIRST conflict
+	g = NewGrammar("List").
+		Add("List", Nonterminal("List"), Literal(","), Terminal(golex.TypeInteger)).
+		Add("List", Terminal(golex.TypeInteger))
+
+	if table, _ := Build(g); table.IsLL1() || table.Conflicts[0].Lookahead != Terminal(golex.TypeInteger) {
+		t.Errorf("Expected left recursion to conflict on Integer but got %v", table.Conflicts)
+	}
+
+	// A literal is also a token of the type it is lexed as
+	g = NewGrammar("A").
+		Add("A", Literal("x"), Terminal(golex.TypeInteger)).
+		Add("A", Terminal(golex.TypeSymbol), Literal("="))
+
+	table, err = Build(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	differ.Compare(`LL(1) conflict: A on "x" predicts A → "x" Integer and A → Symbol "="`, table.Err().Error())
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+
+	// Unless the lexer gives the literal a type of its own
+	if table, err := Build(g.LexWith(golex.WithKeywords("x"))); err != nil || !table.IsLL1() {
+		t.Errorf("Expected the keyword not to conflict with Symbol but got %v", table.Err())
+	}
+
+	g = NewGrammar("A").
+		Add("A", Literal("("), Terminal(golex.TypeInteger)).
+		Add("A", Terminal(golex.TypeOpenParen), Terminal(golex.TypeSymbol)).
+		Add("A", Literal("x="))
+
+	if table, _ := Build(g); len(table.Conflicts) != 1 || table.Conflicts[0].Lookahead != Literal("(") {
+		t.Errorf("Expected a conflict on '(' only but got %v", table.Err())
+	}
+
+	if _, err := Build(NewGrammar("A").Add("A", Literal("x")).LexWith(golex.WithLiteralTokens(golex.LiteralToken{Type: golex.CustomType("Semicolon"), Literal: "%"}))); err == nil {
+		t.Errorf("Expected the error of the lexer options")
+	}
+
+	if _, err := Build(NewGrammar("A").Add("A", Nonterminal("B"))); !errors.Is(err, golex.ErrUndefined) || err.Error() != "undefined nonterminal B in A → B" {
+		t.Errorf("Expected an undefined nonterminal error but got %v", err)
+	}
+}
+
+func TestTableParse(t *testing.T) {
+	fmt.Println("TestTableParse...")
+
+	table, _ := Build(getExpressionGrammar())
+
+	tree, err := table.Parse(getLexer("1 + 2 * (3)"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	differ := &golex.Differ{}
+	differ.Compare(
+		`(Expr (Term (Factor (Integer "1"))) (Plus "+") (Term (Factor (Integer "2")) (Multiply "*")`+
+			` (Factor (OpenParenthesis "(") (Expr (Term (Factor (Integer "3")))) (CloseParenthesis ")"))))`,
+		ast.SExpr(tree),
+	)
+
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+
+	if tree.Parent != nil || tree.Span.End.Col != 12 {
+		t.Errorf("Expected the root to span the whole source but got %s", tree.Span)
+	}
+
+	_, err = table.Parse(getLexer("1 + * 2"))
+	if !errors.Is(err, golex.ErrUnexpectedToken) || err.Error() != `1:5: expected '(' or integer but found '*'` {
+		t.Errorf("Expected an unexpected token error but got %v", err)
+	}
+
+	if _, err := table.Parse(getLexer("(1")); !errors.Is(err, golex.ErrUnexpectedEOF) {
+		t.Errorf("Expected an unexpected EOF error but got %v", err)
+	}
+
+	if _, err := table.Parse(getLexer("1 \"2")); !errors.Is(err, golex.ErrUnterminatedString) {
+		t.Errorf("Expected the lexer error but got %v", err)
+	}
+}
+
+func TestFromEBNF(t *testing.T) {
+	fmt.Println("TestFromEBNF...")
+
+	grammar, err := ebnf.Parse("", `
+Program    = { Statement } .
+Statement  = ( "let" Symbol "=" Expression | Expression ) ";" .
+Expression = Term { ( "+" | "-" ) Term } .
+Term       = Integer | Symbol [ "(" [ Expression { "," Expression } ] ")" ] .
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := FromEBNF(grammar, "Program")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// "let" conflicts with the expressions starting with a Symbol unless it is a keyword
+	if table, _ := Build(g); table.IsLL1() || table.Conflicts[0].Lookahead != Literal("let") {
+		t.Errorf("Expected a conflict on let but got %v", table.Err())
+	}
+
+	table, err := Build(g.LexWith(golex.WithKeywords("let")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !table.IsLL1() {
+		t.Fatalf("Expected the grammar to be LL(1) but got %v", table.Err())
+	}
+
+	// The table driven parser produces the same tree as the EBNF parser
+	source := "let x = 1 + f(2, y); f();"
+	tokens := golextest.Tokenize(t, source, golex.WithKeywords("let"))
+
+	expected, err := grammar.Parse("Program", tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree, err := table.Parse(getLexer(source, golex.WithKeywords("let")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	differ := &golex.Differ{}
+	differ.Compare(ast.SExpr(expected), ast.SExpr(tree))
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+
+	// An option which can be followed by what it starts with is not LL(1)
+	grammar, _ = ebnf.Parse("", `List = Integer [ "," Integer ] { "," Integer } .`)
+	g, _ = FromEBNF(grammar, "List")
+
+	if table, _ := Build(g); len(table.Conflicts) != 1 || table.Conflicts[0].Nonterminal != "List.1" {
+		t.Errorf("Expected the option to conflict on ',' but got %v", table.Err())
+	}
+}
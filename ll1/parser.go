@@ -0,0 +1,101 @@
+package ll1
+
+import (
+	"github.com/cornejong/golex"
+	"github.com/cornejong/golex/ast"
+	"github.com/cornejong/golex/parse"
+)
+
+// TokenSource is the token input of the parser, it is implemented by *golex.Lexer
+type TokenSource interface {
+	NextToken() (golex.Token, error)
+}
+
+// frame is an entry of the parse stack, a symbol to expand or match, or the end of a production
+type frame struct {
+	symbol Symbol
+	reduce bool
+}
+
+// Parse parses the tokens of the source as the start nonterminal using the table.
+//
+// The parser holds a stack of the symbols it expects. A nonterminal on top of
+// the stack is replaced by the symbols of the production the table predicts for
+// the next token, a terminal on top has to match the next token. When a cell of
+// the table has a conflict the first production is used.
+//
+// The tree has a node for every nonterminal, except inlined ones, with leaves for
+// the matched tokens. A syntax error is returned as a *parse.Error, holding the
+// tokens the table expected.
+func (t *Table) Parse(source TokenSource) (*ast.Node, error) {
+	token, err := source.NextToken()
+	if err != nil {
+		return nil, err
+	}
+
+	stack := []frame{{symbol: Terminal(golex.TypeEof)}, {symbol: Nonterminal(t.Grammar.Start)}}
+	// nodes holds the nodes of the productions being parsed, below a holder of the root
+	nodes := []*ast.Node{{}}
+
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		switch {
+		case top.reduce:
+			node := nodes[len(nodes)-1]
+			nodes = nodes[:len(nodes)-1]
+
+			// Nodes are added to their parent once complete, so the span of the parent covers all of them
+			if t.Grammar.inline[top.symbol.Name] && top.symbol.Name != t.Grammar.Start {
+				nodes[len(nodes)-1].Append(node.Children...)
+			} else {
+				nodes[len(nodes)-1].Append(node)
+			}
+
+		case top.symbol.IsTerminal():
+			if !top.symbol.Matches(token) {
+				return nil, &parse.Error{Expected: []golex.Token{top.symbol.Pattern()}, Found: token}
+			}
+
+			if token.TypeIs(golex.TypeEof) {
+				break
+			}
+
+			nodes[len(nodes)-1].Append(ast.NewLeaf(ast.Kind(token.Type.String()), token))
+
+			if token, err = source.NextToken(); err != nil {
+				return nil, err
+			}
+
+		default:
+			indexes := t.lookup(top.symbol.Name, token)
+			if len(indexes) == 0 {
+				return nil, t.unexpected(top.symbol.Name, token)
+			}
+
+			production := t.Grammar.Productions[indexes[0]]
+			nodes = append(nodes, &ast.Node{Kind: ast.Kind(production.Name)})
+
+			stack = append(stack, frame{symbol: top.symbol, reduce: true})
+			for i := len(production.Symbols) - 1; i >= 0; i-- {
+				stack = append(stack, frame{symbol: production.Symbols[i]})
+			}
+		}
+	}
+
+	root := nodes[0].Children[0]
+	root.Parent = nil
+
+	return root, nil
+}
+
+// unexpected returns the error for a token which has no entry for the nonterminal
+func (t *Table) unexpected(name string, token golex.Token) error {
+	err := &parse.Error{Found: token}
+	for _, symbol := range t.lookaheads[name].Sorted() {
+		err.Expected = append(err.Expected, symbol.Pattern())
+	}
+
+	return err
+}
@@ -0,0 +1,302 @@
+package ll1
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cornejong/golex"
+)
+
+// ErrConflict is the kind of the errors of the conflicts of a table
+var ErrConflict = errors.New("LL(1) conflict")
+
+// TerminalSet is a set of terminals keyed by their string
+type TerminalSet map[string]Symbol
+
+func (s TerminalSet) add(symbols ...Symbol) bool {
+	added := false
+	for _, symbol := range symbols {
+		if _, ok := s[symbol.String()]; !ok {
+			s[symbol.String()] = symbol
+			added = true
+		}
+	}
+
+	return added
+}
+
+func (s TerminalSet) addSet(other TerminalSet) bool {
+	added := false
+	for key, symbol := range other {
+		if _, ok := s[key]; !ok {
+			s[key] = symbol
+			added = true
+		}
+	}
+
+	return added
+}
+
+// Contains reports whether the set contains the terminal
+func (s TerminalSet) Contains(symbol Symbol) bool {
+	_, ok := s[symbol.String()]
+	return ok
+}
+
+// Sorted returns the terminals sorted by their string
+func (s TerminalSet) Sorted() []Symbol {
+	symbols := make([]Symbol, 0, len(s))
+	for _, key := range slices.Sorted(maps.Keys(s)) {
+		symbols = append(symbols, s[key])
+	}
+
+	return symbols
+}
+
+func (s TerminalSet) String() string {
+	keys := []string{}
+	for _, symbol := range s.Sorted() {
+		keys = append(keys, symbol.String())
+	}
+
+	return "{" + strings.Join(keys, ", ") + "}"
+}
+
+// Conflict is a cell of the table which predicts more than one production
+type Conflict struct {
+	Nonterminal string
+	Lookahead   Symbol
+	Productions []Production
+}
+
+func (c Conflict) Error() string {
+	productions := make([]string, len(c.Productions))
+	for i, production := range c.Productions {
+		productions[i] = production.String()
+	}
+
+	return fmt.Sprintf("%s: %s on %s predicts %s", ErrConflict, c.Nonterminal, c.Lookahead, strings.Join(productions, " and "))
+}
+
+// Unwrap returns ErrConflict so conflicts can be matched using errors.Is
+func (c Conflict) Unwrap() error { return ErrConflict }
+
+// ###################################################
+// #              Table
+// ###################################################
+
+// Table is the predictive parse table of a grammar
+type Table struct {
+	Grammar *Grammar
+	// Nullable holds the nonterminals which can derive ε
+	Nullable map[string]bool
+	First    map[string]TerminalSet
+	Follow   map[string]TerminalSet
+	// Conflicts holds every cell predicting more than one production, in the order of the nonterminals
+	Conflicts []Conflict
+
+	// predict maps a nonterminal and the string of a lookahead terminal to the indexes of the predicted productions
+	predict map[string]map[string][]int
+	// lookaheads holds the terminals with an entry in the table per nonterminal
+	lookaheads map[string]TerminalSet
+	// literalTypes maps the literal terminals to the token type they are lexed as
+	literalTypes map[string]golex.TokenType
+}
+
+// Build computes the FIRST and FOLLOW sets of the grammar and fills the predictive table.
+// Conflicts do not make it fail, they are collected in the table. A grammar is LL(1) when
+// the table has no conflicts.
+func Build(g *Grammar) (*Table, error) {
+	if err := g.verify(); err != nil {
+		return nil, err
+	}
+
+	lexer := golex.NewLexer(g.lexerOptions...)
+	if err := lexer.Err(); err != nil {
+		return nil, err
+	}
+
+	t := &Table{
+		Grammar:      g,
+		Nullable:     map[string]bool{},
+		First:        map[string]TerminalSet{},
+		Follow:       map[string]TerminalSet{},
+		predict:      map[string]map[string][]int{},
+		lookaheads:   map[string]TerminalSet{},
+		literalTypes: map[string]golex.TokenType{},
+	}
+
+	for _, name := range g.Nonterminals() {
+		t.First[name] = TerminalSet{}
+		t.Follow[name] = TerminalSet{}
+		t.predict[name] = map[string][]int{}
+		t.lookaheads[name] = TerminalSet{}
+	}
+
+	for _, production := range g.Productions {
+		for _, symbol := range production.Symbols {
+			if symbol.Literal == "" {
+				continue
+			}
+
+			// A literal lexed as more than a single token can not overlap a token type
+			if tokens, err := lexer.TokenizeToSlice(symbol.Literal); err == nil && len(tokens) == 2 {
+				t.literalTypes[symbol.Literal] = tokens[0].Type
+			}
+		}
+	}
+
+	t.computeFirst()
+	t.computeFollow()
+	t.fill()
+
+	return t, nil
+}
+
+// IsLL1 reports whether the table has no conflicts
+func (t *Table) IsLL1() bool { return len(t.Conflicts) == 0 }
+
+// Err returns the conflicts joined in a single error, or nil when there are none
+func (t *Table) Err() error {
+	errs := make([]error, len(t.Conflicts))
+	for i, conflict := range t.Conflicts {
+		errs[i] = conflict
+	}
+
+	return errors.Join(errs...)
+}
+
+// FirstOf returns the terminals that can start the symbols and whether the symbols can derive ε
+func (t *Table) FirstOf(symbols []Symbol) (TerminalSet, bool) {
+	first := TerminalSet{}
+
+	for _, symbol := range symbols {
+		if symbol.IsTerminal() {
+			first.add(symbol)
+			return first, false
+		}
+
+		first.addSet(t.First[symbol.Name])
+		if !t.Nullable[symbol.Name] {
+			return first, false
+		}
+	}
+
+	return first, true
+}
+
+// Predict returns the productions predicted for the nonterminal when the token is next
+func (t *Table) Predict(name string, token golex.Token) []Production {
+	indexes := t.lookup(name, token)
+
+	productions := make([]Production, len(indexes))
+	for i, index := range indexes {
+		productions[i] = t.Grammar.Productions[index]
+	}
+
+	return productions
+}
+
+// lookup returns the indexes of the predicted productions. Literals take precedence over
+// token types, a literal which is also predicted by its token type is reported as a conflict.
+func (t *Table) lookup(name string, token golex.Token) []int {
+	if token.Literal != "" && !token.TypeIs(golex.TypeEof) {
+		if indexes, ok := t.predict[name][strconv.Quote(token.Literal)]; ok {
+			return indexes
+		}
+	}
+
+	return t.predict[name][token.Type.String()]
+}
+
+func (t *Table) computeFirst() {
+	for changed := true; changed; {
+		changed = false
+
+		for _, production := range t.Grammar.Productions {
+			first, nullable := t.FirstOf(production.Symbols)
+
+			if t.First[production.Name].addSet(first) {
+				changed = true
+			}
+
+			if nullable && !t.Nullable[production.Name] {
+				t.Nullable[production.Name] = true
+				changed = true
+			}
+		}
+	}
+}
+
+func (t *Table) computeFollow() {
+	t.Follow[t.Grammar.Start].add(Terminal(golex.TypeEof))
+
+	for changed := true; changed; {
+		changed = false
+
+		for _, production := range t.Grammar.Productions {
+			for i, symbol := range production.Symbols {
+				if symbol.IsTerminal() {
+					continue
+				}
+
+				first, nullable := t.FirstOf(production.Symbols[i+1:])
+				if t.Follow[symbol.Name].addSet(first) {
+					changed = true
+				}
+
+				if nullable && t.Follow[symbol.Name].addSet(t.Follow[production.Name]) {
+					changed = true
+				}
+			}
+		}
+	}
+}
+
+func (t *Table) fill() {
+	for index, production := range t.Grammar.Productions {
+		first, nullable := t.FirstOf(production.Symbols)
+		if nullable {
+			first.addSet(t.Follow[production.Name])
+		}
+
+		for key := range first {
+			t.predict[production.Name][key] = append(t.predict[production.Name][key], index)
+		}
+
+		t.lookaheads[production.Name].addSet(first)
+	}
+
+	for _, name := range t.Grammar.Nonterminals() {
+		for _, lookahead := range t.lookaheads[name].Sorted() {
+			indexes := t.predict[name][lookahead.String()]
+
+			// The token of a literal also matches the productions predicted by its token type
+			if tokenType, ok := t.literalTypes[lookahead.Literal]; ok {
+				indexes = slices.Clone(indexes)
+				for _, index := range t.predict[name][tokenType.String()] {
+					if !slices.Contains(indexes, index) {
+						indexes = append(indexes, index)
+					}
+				}
+
+				slices.Sort(indexes)
+			}
+
+			if len(indexes) < 2 {
+				continue
+			}
+
+			conflict := Conflict{Nonterminal: name, Lookahead: lookahead}
+			for _, index := range indexes {
+				conflict.Productions = append(conflict.Productions, t.Grammar.Productions[index])
+			}
+
+			t.Conflicts = append(t.Conflicts, conflict)
+		}
+	}
+}
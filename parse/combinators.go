@@ -0,0 +1,234 @@
+package parse
+
+import (
+	"sync"
+
+	"github.com/cornejong/golex"
+)
+
+// ###################################################
+// #              Tokens
+// ###################################################
+
+// Token matches a token using Token.Is, so the pattern can use
+// golex.AnyTokenType and an optional literal
+func Token(pattern golex.Token) Parser[golex.Token] {
+	return func(s *State) (golex.Token, bool) {
+		token := s.tokens.Peek(1)
+		if !token.Is(pattern) {
+			s.Fail(pattern)
+			return token, false
+		}
+
+		s.tokens.Advance()
+		return token, true
+	}
+}
+
+// Type matches a token of any of the token types
+func Type(types ...golex.TokenType) Parser[golex.Token] {
+	return func(s *State) (golex.Token, bool) {
+		token := s.tokens.Peek(1)
+		if !token.TypeIsAnyOf(types...) {
+			for _, tokenType := range types {
+				s.Fail(golex.Token{Type: tokenType})
+			}
+
+			return token, false
+		}
+
+		s.tokens.Advance()
+		return token, true
+	}
+}
+
+// Literal matches a token of any type with the literal
+func Literal(literal string) Parser[golex.Token] {
+	return Token(golex.Token{Type: golex.AnyTokenType, Literal: literal})
+}
+
+// EOF matches the end of the tokens
+func EOF() Parser[golex.Token] {
+	return Type(golex.TypeEof)
+}
+
+// ###################################################
+// #              Combinators
+// ###################################################
+
+// Seq matches all the parsers in order
+func Seq[T any](parsers ...Parser[T]) Parser[[]T] {
+	return func(s *State) ([]T, bool) {
+		mark := s.mark()
+		values := make([]T, 0, len(parsers))
+
+		for _, p := range parsers {
+			value, ok := p(s)
+			if !ok {
+				s.reset(mark)
+				return nil, false
+			}
+
+			values = append(values, value)
+		}
+
+		return values, true
+	}
+}
+
+// Seq2 matches both parsers in order and combines their values
+func Seq2[A, B, R any](a Parser[A], b Parser[B], combine func(A, B) R) Parser[R] {
+	return func(s *State) (R, bool) {
+		mark := s.mark()
+
+		var result R
+		first, ok := a(s)
+		if !ok {
+			return result, false
+		}
+
+		second, ok := b(s)
+		if !ok {
+			s.reset(mark)
+			return result, false
+		}
+
+		return combine(first, second), true
+	}
+}
+
+// Seq3 matches the three parsers in order and combines their values
+func Seq3[A, B, C, R any](a Parser[A], b Parser[B], c Parser[C], combine func(A, B, C) R) Parser[R] {
+	return func(s *State) (R, bool) {
+		mark := s.mark()
+
+		var result R
+		first, ok := a(s)
+		if !ok {
+			return result, false
+		}
+
+		second, ok := b(s)
+		if !ok {
+			s.reset(mark)
+			return result, false
+		}
+
+		third, ok := c(s)
+		if !ok {
+			s.reset(mark)
+			return result, false
+		}
+
+		return combine(first, second, third), true
+	}
+}
+
+// Alt matches the first of the parsers that succeeds
+func Alt[T any](parsers ...Parser[T]) Parser[T] {
+	return func(s *State) (T, bool) {
+		mark := s.mark()
+
+		for _, p := range parsers {
+			if value, ok := p(s); ok {
+				return value, true
+			}
+
+			s.reset(mark)
+		}
+
+		var zero T
+		return zero, false
+	}
+}
+
+// Many matches the parser zero or more times
+func Many[T any](p Parser[T]) Parser[[]T] {
+	return func(s *State) ([]T, bool) {
+		values := []T{}
+
+		for {
+			mark := s.mark()
+			value, ok := p(s)
+			if !ok {
+				s.reset(mark)
+				return values, true
+			}
+
+			// A parser that matches without consuming would match forever
+			if s.tokens.Cursor() == mark.cursor {
+				return values, true
+			}
+
+			values = append(values, value)
+		}
+	}
+}
+
+// Many1 matches the parser one or more times
+func Many1[T any](p Parser[T]) Parser[[]T] {
+	return Seq2(p, Many(p), func(first T, rest []T) []T {
+		return append([]T{first}, rest...)
+	})
+}
+
+// Optional matches the parser or nothing, in which case the value is the zero value of T
+func Optional[T any](p Parser[T]) Parser[T] {
+	return func(s *State) (T, bool) {
+		mark := s.mark()
+
+		value, ok := p(s)
+		if !ok {
+			s.reset(mark)
+
+			var zero T
+			return zero, true
+		}
+
+		return value, true
+	}
+}
+
+// SepBy matches the parser zero or more times, separated by the separator.
+// A trailing separator is not consumed.
+func SepBy[T, S any](p Parser[T], separator Parser[S]) Parser[[]T] {
+	return Alt(SepBy1(p, separator), func(s *State) ([]T, bool) { return []T{}, true })
+}
+
+// SepBy1 matches the parser one or more times, separated by the separator.
+// A trailing separator is not consumed.
+func SepBy1[T, S any](p Parser[T], separator Parser[S]) Parser[[]T] {
+	next := Seq2(separator, p, func(_ S, value T) T { return value })
+
+	return Seq2(p, Many(next), func(first T, rest []T) []T {
+		return append([]T{first}, rest...)
+	})
+}
+
+// Between matches the parser between the open and close parsers, returning the value of the parser
+func Between[O, T, C any](open Parser[O], p Parser[T], close Parser[C]) Parser[T] {
+	return Seq3(open, p, close, func(_ O, value T, _ C) T { return value })
+}
+
+// Map converts the value of the parser
+func Map[T, R any](p Parser[T], convert func(T) R) Parser[R] {
+	return func(s *State) (R, bool) {
+		value, ok := p(s)
+		if !ok {
+			var zero R
+			return zero, false
+		}
+
+		return convert(value), true
+	}
+}
+
+// Lazy creates the parser on first use, allowing recursive grammars.
+// The parser is created once, also when the grammar is shared between goroutines.
+func Lazy[T any](create func() Parser[T]) Parser[T] {
+	p := sync.OnceValue(create)
+
+	return func(s *State) (T, bool) {
+		return p()(s)
+	}
+}
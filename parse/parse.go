@@ -0,0 +1,212 @@
+// Package parse provides typed parser combinators over golex tokens.
+//
+// A Parser consumes tokens from a State, which wraps a golex.TokenCollection.
+// Parsers backtrack on failure and record what they expected at the cursor.
+// The failure which got the furthest into the tokens is reported, so the
+// error points at the most likely location of the actual mistake.
+//
+// Recover, Expect and ParseTolerant make parsers error tolerant: instead of
+// failing they record a diagnostic, skip to a synchronisation token and put
+// Error and Missing nodes in the tree, so broken input still yields a tree.
+package parse
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cornejong/golex"
+)
+
+// Parser parses a value of type T at the cursor of the state.
+// On failure it returns false, leaving the cursor where it was.
+type Parser[T any] func(s *State) (T, bool)
+
+// State is the input of the parsers, a cursor over the tokens which records the furthest failure
+type State struct {
+	tokens      *golex.TokenCollection
+	failure     *Error
+	failureAt   int
+	diagnostics []golex.Diagnostic
+}
+
+// mark is a position to backtrack to, diagnostics reported after it are dropped on reset
+type mark struct {
+	cursor      int
+	diagnostics int
+}
+
+// NewState creates a state at the cursor of the tokens
+func NewState(tokens *golex.TokenCollection) *State {
+	return &State{tokens: tokens, failureAt: -1}
+}
+
+// Tokens returns the token collection of the state
+func (s *State) Tokens() *golex.TokenCollection { return s.tokens }
+
+// Fail records that one of the expected tokens was expected at the cursor.
+// Failures before the furthest failure are ignored, failures at the same
+// position add their expected tokens to it.
+func (s *State) Fail(expected ...golex.Token) {
+	at := s.tokens.Cursor()
+
+	if at > s.failureAt {
+		s.failure = &Error{Found: s.tokens.Peek(1)}
+		s.failureAt = at
+	}
+
+	if at == s.failureAt {
+		s.failure.AddExpected(expected...)
+	}
+}
+
+// Report records a diagnostic about the tokens, it is dropped when the parser reporting it backtracks
+func (s *State) Report(diagnostic golex.Diagnostic) {
+	s.diagnostics = append(s.diagnostics, diagnostic)
+}
+
+// Diagnostics returns the diagnostics reported by the parsers
+func (s *State) Diagnostics() []golex.Diagnostic { return s.diagnostics }
+
+func (s *State) mark() mark {
+	return mark{cursor: s.tokens.Mark(), diagnostics: len(s.diagnostics)}
+}
+
+func (s *State) reset(m mark) {
+	s.tokens.Reset(m.cursor)
+	s.diagnostics = s.diagnostics[:m.diagnostics]
+}
+
+// Err returns the furthest failure or nil
+func (s *State) Err() error {
+	if s.failure == nil {
+		return nil
+	}
+
+	return s.failure
+}
+
+// Run runs the parser at the cursor of the tokens, returning the furthest failure when it fails
+func Run[T any](p Parser[T], tokens *golex.TokenCollection) (T, error) {
+	s := NewState(tokens)
+
+	value, ok := p(s)
+	if !ok {
+		return value, s.Err()
+	}
+
+	return value, nil
+}
+
+// Parse runs the parser over all the tokens, which have to be consumed up to the end
+func Parse[T any](p Parser[T], tokens golex.Tokens) (T, error) {
+	collection := golex.NewTokenCollection(tokens)
+	s := NewState(&collection)
+
+	value, ok := p(s)
+	if ok {
+		_, ok = EOF()(s)
+	}
+
+	if !ok {
+		return value, s.Err()
+	}
+
+	return value, nil
+}
+
+// ###################################################
+// #              Errors
+// ###################################################
+
+// Error is a parse failure, holding the tokens that were expected and the token that was found.
+// Parsers backtrack, so the failure is reported at the furthest position the parser got to.
+type Error struct {
+	Expected []golex.Token
+	Found    golex.Token
+}
+
+// AddExpected adds the patterns to the expected tokens, skipping the patterns with the type
+// and literal of a token already expected. Values are not compared, they may not be comparable.
+func (e *Error) AddExpected(patterns ...golex.Token) {
+	for _, pattern := range patterns {
+		if !slices.ContainsFunc(e.Expected, func(token golex.Token) bool {
+			return token.Type == pattern.Type && token.Literal == pattern.Literal
+		}) {
+			e.Expected = append(e.Expected, pattern)
+		}
+	}
+}
+
+// Position returns the position of the failure
+func (e *Error) Position() golex.Position { return e.Found.Position }
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Found.Position.Location(), e.message())
+}
+
+// message returns the description of the failure without its location
+func (e *Error) message() string {
+	if len(e.Expected) == 0 {
+		return fmt.Sprintf("unexpected %s", describeToken(e.Found))
+	}
+
+	expected := []string{}
+	for _, token := range e.Expected {
+		expected = append(expected, describePattern(token))
+	}
+
+	return fmt.Sprintf("expected %s but found %s", joinAlternatives(expected), describeToken(e.Found))
+}
+
+// Diagnostic converts the failure to a diagnostic labelling the token that was found.
+// The source is not known to the parser, set it on the diagnostic to render the excerpt.
+func (e *Error) Diagnostic() golex.Diagnostic {
+	d := golex.Diagnostic{
+		Severity: golex.SeverityError,
+		Code:     e.Unwrap().(*golex.ErrorKind).Code(),
+		Message:  e.message(),
+	}
+
+	if e.Found.Position.Row > 0 {
+		d.Labels = append(d.Labels, golex.PrimaryLabel(e.Found.Position, e.Found.End, ""))
+	}
+
+	return d
+}
+
+// Unwrap returns the golex error kind so the error can be matched using errors.Is
+func (e *Error) Unwrap() error {
+	if e.Found.TypeIs(golex.TypeEof) {
+		return golex.ErrUnexpectedEOF
+	}
+
+	return golex.ErrUnexpectedToken
+}
+
+// describePattern returns a human-readable description of a token pattern
+func describePattern(pattern golex.Token) string {
+	if pattern.Literal != "" {
+		return fmt.Sprintf("'%s'", pattern.Literal)
+	}
+
+	return golex.DisplayName(pattern.Type)
+}
+
+// describeToken returns a human-readable description of a token, including its literal when the name does not
+func describeToken(token golex.Token) string {
+	name := golex.DisplayName(token.Type)
+	if token.Literal == "" || token.TypeIs(golex.TypeEof) || strings.HasPrefix(name, "'") {
+		return name
+	}
+
+	return fmt.Sprintf("%s '%s'", name, token.Literal)
+}
+
+func joinAlternatives(alternatives []string) string {
+	if len(alternatives) < 2 {
+		return strings.Join(alternatives, "")
+	}
+
+	return strings.Join(alternatives[:len(alternatives)-1], ", ") + " or " + alternatives[len(alternatives)-1]
+}
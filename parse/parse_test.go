@@ -0,0 +1,203 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cornejong/golex"
+	"github.com/cornejong/golex/ast"
+	"github.com/cornejong/golex/internal/golextest"
+)
+
+// value = integer | call | symbol | list
+// call  = symbol "(" [ value { "," value } ] ")"
+// list  = "[" [ value { "," value } ] "]"
+func valueParser() Parser[string] {
+	var value Parser[string]
+
+	join := func(values []string) string { return strings.Join(values, " ") }
+
+	literal := Map(Type(golex.TypeInteger, golex.TypeSymbol), func(token golex.Token) string { return token.Literal })
+	args := Map(SepBy(Lazy(func() Parser[string] { return value }), Type(golex.TypeComma)), join)
+
+	call := Seq2(Type(golex.TypeSymbol), Between(Type(golex.TypeOpenParen), args, Type(golex.TypeCloseParen)), func(name golex.Token, args string) string {
+		return fmt.Sprintf("(%s %s)", name.Literal, args)
+	})
+
+	list := Map(Between(Literal("["), args, Literal("]")), func(args string) string {
+		return fmt.Sprintf("[%s]", args)
+	})
+
+	value = Alt(call, literal, list)
+	return value
+}
+
+func TestParseCombinators(t *testing.T) {
+	fmt.Println("TestParseCombinators...")
+
+	value, err := Parse(valueParser(), golextest.Tokenize(t, "f(a, [1, g(), [b]], 2)"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if value != "(f a [1 (g ) [b]] 2)" {
+		t.Errorf("Unexpected parse result %q", value)
+	}
+
+	values, err := Parse(Many(Optional(Type(golex.TypeSemicolon))), golextest.Tokenize(t, ";;"))
+	if err != nil || len(values) != 2 {
+		t.Errorf("Expected two semicolons but got %d (%v)", len(values), err)
+	}
+
+	values, err = Parse(Seq(Literal("a"), Literal("b")), golextest.Tokenize(t, "a b"))
+	if err != nil || len(values) != 2 {
+		t.Errorf("Expected a sequence of two tokens but got %d (%v)", len(values), err)
+	}
+}
+
+func TestParseFurthestFailure(t *testing.T) {
+	fmt.Println("TestParseFurthestFailure...")
+
+	_, err := Parse(valueParser(), golextest.Tokenize(t, "f(a, [1, 2 3])"))
+
+	var parseErr *Error
+	if !errors.As(err, &parseErr) || !errors.Is(err, golex.ErrUnexpectedToken) {
+		t.Fatalf("Expected a parse error but got %v", err)
+	}
+
+	expect := "1:12: expected ',' or ']' but found integer '3'"
+	if err.Error() != expect {
+		t.Errorf("Expected the error %q but got %q", expect, err.Error())
+	}
+
+	if parseErr.Position().Col != 12 {
+		t.Errorf("Expected the error at column 12 but got %d", parseErr.Position().Col)
+	}
+
+	_, err = Parse(valueParser(), golextest.Tokenize(t, "f(a"))
+	if !errors.Is(err, golex.ErrUnexpectedEOF) {
+		t.Errorf("Expected an unexpected EOF error but got %v", err)
+	}
+
+	// Patterns are deduplicated on their type and literal, their values may not be comparable
+	pattern := golex.Token{Type: golex.TypeSymbol, Value: []string{"name"}}
+	_, err = Parse(Alt(Token(pattern), Token(pattern)), golextest.Tokenize(t, "1"))
+	if !errors.As(err, &parseErr) || len(parseErr.Expected) != 1 {
+		t.Errorf("Expected a single expected symbol but got %v", err)
+	}
+}
+
+func TestParseLazyConcurrent(t *testing.T) {
+	fmt.Println("TestParseLazyConcurrent...")
+
+	created := atomic.Int32{}
+	value := Lazy(func() Parser[string] {
+		created.Add(1)
+		return valueParser()
+	})
+
+	// A grammar shared between goroutines creates the lazy parser once
+	tokens := golextest.Tokenize(t, "f(a, [1])")
+	wg := sync.WaitGroup{}
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if result, err := Parse(value, tokens); err != nil || result != "(f a [1])" {
+				t.Errorf("Expected (f a [1]) but got %q, %v", result, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if created.Load() != 1 {
+		t.Errorf("Expected the parser to be created once but got %d", created.Load())
+	}
+}
+
+// block     = "{" { statement } "}"
+// statement = symbol "=" integer ";"
+func blockParser() Parser[*ast.Node] {
+	leaf := func(p Parser[golex.Token]) Parser[*ast.Node] {
+		return Map(p, func(token golex.Token) *ast.Node { return ast.NewLeaf(ast.Kind(token.Type.String()), token) })
+	}
+
+	statement := Map(Seq(leaf(Type(golex.TypeSymbol)), leaf(Literal("=")), leaf(Type(golex.TypeInteger)), Expect(golex.Token{Type: golex.TypeSemicolon})), func(children []*ast.Node) *ast.Node {
+		return ast.NewNode("Statement", children...)
+	})
+
+	statements := Many(Recover(statement, SyncSet{After: []golex.TokenType{golex.TypeSemicolon}, Before: []golex.TokenType{golex.TypeCloseCurly}}))
+
+	return Seq3(Expect(golex.Token{Type: golex.TypeOpenCurly}), statements, Expect(golex.Token{Type: golex.TypeCloseCurly}), func(open *ast.Node, statements []*ast.Node, close *ast.Node) *ast.Node {
+		return ast.NewNode("Block", append(append([]*ast.Node{open}, statements...), close)...)
+	})
+}
+
+func TestParseRecovery(t *testing.T) {
+	fmt.Println("TestParseRecovery...")
+
+	tree, diagnostics := ParseTolerant(blockParser(), golextest.Tokenize(t, "{ a = 1; b = ; c = 3 d = 4; }"))
+
+	differ := &golex.Differ{}
+	differ.Compare(
+		`(Block (OpenCurlyBracket "{") (Statement (Symbol "a") (Assign "=") (Integer "1") (Semicolon ";"))`+
+			` (Error (Symbol "b") (Assign "=") (Semicolon ";"))`+
+			` (Statement (Symbol "c") (Assign "=") (Integer "3") (Missing Semicolon))`+
+			` (Statement (Symbol "d") (Assign "=") (Integer "4") (Semicolon ";")) (CloseCurlyBracket "}"))`,
+		ast.SExpr(tree),
+	)
+
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+
+	messages := []string{}
+	for _, diagnostic := range diagnostics {
+		messages = append(messages, fmt.Sprintf("%s %s: %s", diagnostic.Code, diagnostic.Labels[0].Start.Location(), diagnostic.Message))
+	}
+
+	differ.Compare([]string{
+		"L0007 1:14: expected integer but found ';'",
+		"L0007 1:22: expected ';' but found symbol 'd'",
+	}, messages)
+
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+
+	missing := tree.Children[3].Children[3]
+	if !missing.IsMissing() || missing.Span.Start.Col != 21 || !tree.HasErrors() {
+		t.Errorf("Expected a missing semicolon directly after the integer but got %s at %s", ast.SExpr(missing), missing.Span)
+	}
+
+	// Missing tokens at the end of the input
+	tree, diagnostics = ParseTolerant(blockParser(), golextest.Tokenize(t, "{ a = 1;"))
+	if len(diagnostics) != 1 || diagnostics[0].Code != golex.ErrUnexpectedEOF.Code() || !tree.Children[2].IsMissing() {
+		t.Errorf("Expected a missing '}' at the end of the input but got %s %v", ast.SExpr(tree), diagnostics)
+	}
+
+	// Tokens after the value are reported
+	_, diagnostics = ParseTolerant(blockParser(), golextest.Tokenize(t, "{ } }"))
+	if len(diagnostics) != 1 || diagnostics[0].Message != "expected end of file but found '}'" {
+		t.Errorf("Expected the trailing '}' to be reported but got %v", diagnostics)
+	}
+
+	// Diagnostics of alternatives which are backtracked are dropped
+	leaf := func(token golex.Token) *ast.Node { return ast.NewLeaf("Leaf", token) }
+	p := Alt(
+		Map(Seq(Expect(golex.Token{Type: golex.TypeSemicolon}), Map(Literal("x"), leaf)), func(nodes []*ast.Node) *ast.Node { return nodes[0] }),
+		Map(Literal("y"), leaf),
+	)
+
+	if _, diagnostics := ParseTolerant(p, golextest.Tokenize(t, "y")); len(diagnostics) != 0 {
+		t.Errorf("Expected no diagnostics but got %v", diagnostics)
+	}
+}
@@ -0,0 +1,120 @@
+package parse
+
+import (
+	"github.com/cornejong/golex"
+	"github.com/cornejong/golex/ast"
+)
+
+// ###################################################
+// #              Error recovery
+// ###################################################
+
+// SyncSet declares the tokens error recovery skips to. Tokens of the After types
+// end a broken construct and are skipped along with it, like a ';'. Tokens of the
+// Before types start or close the surrounding construct and are left for it, like a '}'.
+// The end of the tokens always stops the skipping.
+type SyncSet struct {
+	After  []golex.TokenType
+	Before []golex.TokenType
+}
+
+// at reports whether the token stops the skipping before it
+func (sync SyncSet) at(token golex.Token) bool {
+	return token.TypeIs(golex.TypeEof) || token.TypeIsAnyOf(sync.Before...)
+}
+
+// Expect matches a token using Token.Is and returns its leaf. When the token is not
+// there it reports a diagnostic and returns a Missing node without consuming anything,
+// like the missing tokens of Roslyn, so the surrounding parser can carry on.
+func Expect(pattern golex.Token) Parser[*ast.Node] {
+	return func(s *State) (*ast.Node, bool) {
+		token := s.tokens.Peek(1)
+		if token.Is(pattern) {
+			s.tokens.Advance()
+			return ast.NewLeaf(ast.Kind(token.Type.String()), token), true
+		}
+
+		s.Report((&Error{Expected: []golex.Token{pattern}, Found: token}).Diagnostic())
+
+		// The missing token belongs directly after the previous token
+		position := token.Position
+		if s.tokens.Cursor() > 0 {
+			position = s.tokens.Peek(0).End
+		}
+
+		return ast.NewMissing(pattern, position), true
+	}
+}
+
+// Recover runs the parser and on failure recovers in panic mode: it reports the furthest
+// failure as a diagnostic, skips the tokens up to the synchronisation set and returns an
+// Error node holding them. It fails without reporting when the parser fails at a Before
+// token or the end of the tokens, as there is nothing to skip and the construct is absent.
+func Recover(p Parser[*ast.Node], sync SyncSet) Parser[*ast.Node] {
+	return RecoverWith(p, sync, func(node *ast.Node) *ast.Node { return node })
+}
+
+// RecoverWith is Recover for parsers of any value, wrap converts the Error node to a value
+func RecoverWith[T any](p Parser[T], sync SyncSet, wrap func(*ast.Node) T) Parser[T] {
+	return func(s *State) (T, bool) {
+		mark := s.mark()
+
+		value, ok := p(s)
+		if ok {
+			return value, true
+		}
+
+		s.reset(mark)
+
+		if sync.at(s.tokens.Peek(1)) {
+			var zero T
+			return zero, false
+		}
+
+		failure := s.failure
+		if failure == nil || s.failureAt < mark.cursor {
+			failure = &Error{Found: s.tokens.Peek(1)}
+		}
+
+		s.Report(failure.Diagnostic())
+
+		// The failure is handled, later failures are reported from scratch
+		s.failure = nil
+		s.failureAt = -1
+
+		skipped := golex.Tokens{}
+		for token := s.tokens.Peek(1); !sync.at(token); token = s.tokens.Peek(1) {
+			skipped = append(skipped, s.tokens.Advance())
+
+			if token.TypeIsAnyOf(sync.After...) {
+				break
+			}
+		}
+
+		return wrap(ast.NewError(skipped...)), true
+	}
+}
+
+// ParseTolerant runs the parser over all the tokens and returns its value along with every
+// diagnostic that was reported. A failure that was not recovered from and tokens left after
+// the value are reported as well, so the value is a best-effort result for broken input.
+func ParseTolerant[T any](p Parser[T], tokens golex.Tokens) (T, []golex.Diagnostic) {
+	collection := golex.NewTokenCollection(tokens)
+	s := NewState(&collection)
+
+	value, ok := p(s)
+	if ok {
+		_, ok = EOF()(s)
+	}
+
+	if !ok {
+		failure := s.failure
+		if failure == nil {
+			failure = &Error{Found: s.tokens.Peek(1)}
+		}
+
+		s.Report(failure.Diagnostic())
+	}
+
+	return value, s.Diagnostics()
+}
@@ -0,0 +1,235 @@
+package peg
+
+import (
+	"slices"
+
+	"github.com/cornejong/golex"
+	"github.com/cornejong/golex/ast"
+	"github.com/cornejong/golex/parse"
+)
+
+// Parser parses tokens using the rules of a grammar
+type Parser struct {
+	Grammar *Grammar
+	// MemoLimit is the maximum number of memoised rule results kept while parsing,
+	// 0 keeps all of them. When the limit is reached the oldest results are dropped
+	// and parsed again when they are needed.
+	MemoLimit int
+}
+
+// ParserOptionFunc sets an option of a packrat parser, like WithMemoLimit
+type ParserOptionFunc func(p *Parser)
+
+// WithMemoLimit bounds the memory of the memo table to the number of rule results
+func WithMemoLimit(entries int) ParserOptionFunc {
+	return ParserOptionFunc(func(p *Parser) {
+		p.MemoLimit = entries
+	})
+}
+
+// NewParser creates a parser for the grammar
+func NewParser(grammar *Grammar, options ...ParserOptionFunc) *Parser {
+	p := &Parser{Grammar: grammar}
+	for _, option := range options {
+		option(p)
+	}
+
+	return p
+}
+
+// Parse parses all the tokens as the start rule, returning the tree of the matched rules.
+// Rules are nodes with the name of the rule as their kind, tokens are leaves with
+// the token type as their kind. A syntax error is returned as a *parse.Error.
+func (p *Parser) Parse(start string, tokens golex.Tokens) (*ast.Node, error) {
+	collection := golex.NewTokenCollection(tokens)
+
+	return p.parse(start, &collection, true)
+}
+
+// Match parses the start rule at the cursor of the tokens, moving the cursor past the match
+func (p *Parser) Match(start string, tokens *golex.TokenCollection) (*ast.Node, error) {
+	return p.parse(start, tokens, false)
+}
+
+func (p *Parser) parse(start string, tokens *golex.TokenCollection, complete bool) (*ast.Node, error) {
+	if err := p.Grammar.verify(start); err != nil {
+		return nil, err
+	}
+
+	r := &run{parser: p, tokens: tokens, memo: map[memoKey]*memoEntry{}, failureAt: -1}
+
+	node, end, ok := r.apply(start, tokens.Cursor())
+	if ok && complete {
+		_, _, ok = EOF().match(r, end)
+	}
+
+	if !ok {
+		// Only predicates failed, which do not record what was expected
+		if r.failure == nil {
+			return nil, &parse.Error{Found: tokens.TokenAtPosition(tokens.Cursor())}
+		}
+
+		return nil, r.failure
+	}
+
+	// Memoised nodes can be attached to parents that were discarded while backtracking
+	ast.Inspect(node, func(n *ast.Node) bool {
+		if n != nil {
+			for _, child := range n.Children {
+				child.Parent = n
+			}
+		}
+
+		return true
+	})
+
+	tokens.IncrementCursor(end - tokens.Cursor())
+	return node, nil
+}
+
+// ###################################################
+// #              Memoisation
+// ###################################################
+
+type memoKey struct {
+	rule string
+	pos  int
+}
+
+type memoEntry struct {
+	key  memoKey
+	node *ast.Node
+	end  int
+	ok   bool
+	// active is set while the rule is being parsed, finding an active entry means the rule is left recursive
+	active bool
+	// head is set when the rule is the head of a left recursion and its seed has to be grown
+	head bool
+	// involved is set when the rule is part of a left recursion at the same position,
+	// its result depends on the current seed so it is not kept
+	involved bool
+}
+
+// run holds the memo table and the rule stack of a single parse
+type run struct {
+	parser *Parser
+	tokens *golex.TokenCollection
+
+	memo map[memoKey]*memoEntry
+	// order holds the memo keys in the order they were stored, used to drop the oldest entries
+	order []memoKey
+	// stack holds the entries of the rules being parsed
+	stack []*memoEntry
+
+	failure    *parse.Error
+	failureAt  int
+	predicates int
+}
+
+// apply parses the rule at the position, using the memoised result when there is one
+func (r *run) apply(rule string, pos int) (*ast.Node, int, bool) {
+	key := memoKey{rule: rule, pos: pos}
+	if entry, ok := r.memo[key]; ok {
+		if entry.active {
+			r.leftRecursion(entry)
+		}
+
+		return entry.node, entry.end, entry.ok
+	}
+
+	// The entry starts as a failure, so a left recursive application fails and the other alternatives plant the seed
+	entry := &memoEntry{key: key, end: pos, active: true}
+	r.store(entry)
+
+	r.stack = append(r.stack, entry)
+	node, end, ok := r.evaluate(rule, pos)
+
+	if entry.head && ok {
+		// Grow the seed until parsing the rule again does not get any further
+		for ok && end > entry.end {
+			entry.node, entry.end, entry.ok = node, end, ok
+			node, end, ok = r.evaluate(rule, pos)
+		}
+
+		node, end, ok = entry.node, entry.end, entry.ok
+	}
+
+	r.stack = r.stack[:len(r.stack)-1]
+	entry.node, entry.end, entry.ok = node, end, ok
+	entry.active = false
+
+	if entry.involved && r.memo[key] == entry {
+		delete(r.memo, key)
+	}
+
+	return node, end, ok
+}
+
+func (r *run) evaluate(rule string, pos int) (*ast.Node, int, bool) {
+	nodes, end, ok := r.parser.Grammar.rules[rule].match(r, pos)
+	if !ok {
+		return nil, pos, false
+	}
+
+	return ast.NewNode(ast.Kind(rule), nodes...), end, true
+}
+
+// leftRecursion marks the entry as the head of a left recursion,
+// and the rules between it and the recursive application as involved
+func (r *run) leftRecursion(head *memoEntry) {
+	head.head = true
+
+	for i := len(r.stack) - 1; i >= 0 && r.stack[i] != head; i-- {
+		r.stack[i].involved = true
+	}
+}
+
+// store memoises the entry, dropping the oldest entries which are not being parsed when the memo limit is reached
+func (r *run) store(entry *memoEntry) {
+	r.memo[entry.key] = entry
+	r.order = append(r.order, entry.key)
+
+	limit := r.parser.MemoLimit
+	if limit <= 0 {
+		return
+	}
+
+	for checked := len(r.order); len(r.memo) > limit && checked > 0; checked-- {
+		key := r.order[0]
+		r.order = r.order[1:]
+
+		if oldest, ok := r.memo[key]; ok && oldest.active {
+			r.order = append(r.order, key)
+			continue
+		}
+
+		delete(r.memo, key)
+	}
+
+	// Keys of entries that were already dropped can pile up, so compact them once in a while
+	if len(r.order) > 2*limit {
+		r.order = slices.DeleteFunc(r.order, func(key memoKey) bool {
+			_, ok := r.memo[key]
+			return !ok
+		})
+	}
+}
+
+// ###################################################
+// #              Errors
+// ###################################################
+
+// fail records that one of the expected tokens was expected at the position,
+// keeping the expectations at the furthest position
+func (r *run) fail(pos int, expected ...golex.Token) {
+	if r.predicates > 0 || pos < r.failureAt {
+		return
+	}
+
+	if pos > r.failureAt {
+		r.failure = &parse.Error{Found: r.tokens.TokenAtPosition(pos)}
+		r.failureAt = pos
+	}
+
+	r.failure.AddExpected(expected...)
+}
@@ -0,0 +1,263 @@
+// Package peg provides a packrat parser for parsing expression grammars over golex tokens.
+//
+// A Grammar maps rule names to parsing expressions: token matches, sequences,
+// ordered choices, repetitions and and/not predicates. The result of every rule
+// application is memoised per (rule, position), so backtracking never parses the
+// same rule at the same position twice and parsing takes linear time. Left
+// recursive rules are supported by growing the seed of the recursion, so
+// operators can be written in their natural left associative form:
+//
+//	Sum = Sum "+" Product / Product
+package peg
+
+import (
+	"fmt"
+
+	"github.com/cornejong/golex"
+	"github.com/cornejong/golex/ast"
+)
+
+// ErrUndefinedRule is golex.ErrUndefined, returned when a grammar references a rule which is not defined
+var ErrUndefinedRule = golex.ErrUndefined
+
+// Grammar holds the rules of a parsing expression grammar
+type Grammar struct {
+	rules map[string]Expression
+	names []string
+}
+
+// NewGrammar creates an empty grammar
+func NewGrammar() *Grammar {
+	return &Grammar{rules: map[string]Expression{}}
+}
+
+// Define sets the expression of the rule
+func (g *Grammar) Define(name string, expr Expression) *Grammar {
+	if _, ok := g.rules[name]; !ok {
+		g.names = append(g.names, name)
+	}
+
+	g.rules[name] = expr
+	return g
+}
+
+// Rules returns the names of the rules in the order they were defined
+func (g *Grammar) Rules() []string { return g.names }
+
+// verify checks that the start rule and every referenced rule is defined
+func (g *Grammar) verify(start string) error {
+	if _, ok := g.rules[start]; !ok {
+		return fmt.Errorf("%w rule %s", ErrUndefinedRule, start)
+	}
+
+	for _, name := range g.names {
+		if err := verifyExpression(g, g.rules[name]); err != nil {
+			return fmt.Errorf("%w in rule %s", err, name)
+		}
+	}
+
+	return nil
+}
+
+func verifyExpression(g *Grammar, expr Expression) error {
+	switch x := expr.(type) {
+	case ruleExpr:
+		if _, ok := g.rules[x.name]; !ok {
+			return fmt.Errorf("%w rule %s", ErrUndefinedRule, x.name)
+		}
+	case seqExpr:
+		for _, e := range x {
+			if err := verifyExpression(g, e); err != nil {
+				return err
+			}
+		}
+	case choiceExpr:
+		for _, e := range x {
+			if err := verifyExpression(g, e); err != nil {
+				return err
+			}
+		}
+	case repeatExpr:
+		return verifyExpression(g, x.expr)
+	case optionalExpr:
+		return verifyExpression(g, x.expr)
+	case predicateExpr:
+		return verifyExpression(g, x.expr)
+	}
+
+	return nil
+}
+
+// ###################################################
+// #              Expressions
+// ###################################################
+
+// Expression is a parsing expression. It matches at a token position and
+// returns the nodes it produced and the position after the match.
+type Expression interface {
+	match(r *run, pos int) ([]*ast.Node, int, bool)
+}
+
+type (
+	tokenExpr  []golex.Token
+	anyExpr    struct{}
+	ruleExpr   struct{ name string }
+	seqExpr    []Expression
+	choiceExpr []Expression
+	repeatExpr struct {
+		expr Expression
+		min  int
+	}
+	optionalExpr  struct{ expr Expression }
+	predicateExpr struct {
+		expr   Expression
+		negate bool
+	}
+)
+
+// Token matches a token which is any of the patterns using Token.Is
+func Token(patterns ...golex.Token) Expression { return tokenExpr(patterns) }
+
+// Type matches a token of any of the token types
+func Type(types ...golex.TokenType) Expression {
+	patterns := make(tokenExpr, len(types))
+	for i, tokenType := range types {
+		patterns[i] = golex.Token{Type: tokenType}
+	}
+
+	return patterns
+}
+
+// Literal matches a token of any type with the literal
+func Literal(literal string) Expression {
+	return tokenExpr{{Type: golex.AnyTokenType, Literal: literal}}
+}
+
+// EOF matches the end of the tokens
+func EOF() Expression { return Type(golex.TypeEof) }
+
+// Any matches any token except the end of the tokens
+func Any() Expression { return anyExpr{} }
+
+// Rule matches the rule with the name, producing a node with the name as its kind
+func Rule(name string) Expression { return ruleExpr{name: name} }
+
+// Seq matches the expressions in order
+func Seq(exprs ...Expression) Expression { return seqExpr(exprs) }
+
+// Choice matches the first of the expressions that succeeds
+func Choice(exprs ...Expression) Expression { return choiceExpr(exprs) }
+
+// ZeroOrMore matches the expression as often as possible
+func ZeroOrMore(expr Expression) Expression { return repeatExpr{expr: expr} }
+
+// OneOrMore matches the expression as often as possible, but at least once
+func OneOrMore(expr Expression) Expression { return repeatExpr{expr: expr, min: 1} }
+
+// Optional matches the expression or nothing
+func Optional(expr Expression) Expression { return optionalExpr{expr: expr} }
+
+// And succeeds when the expression matches, without consuming any tokens
+func And(expr Expression) Expression { return predicateExpr{expr: expr} }
+
+// Not succeeds when the expression does not match, without consuming any tokens
+func Not(expr Expression) Expression { return predicateExpr{expr: expr, negate: true} }
+
+func (x tokenExpr) match(r *run, pos int) ([]*ast.Node, int, bool) {
+	token := r.tokens.TokenAtPosition(pos)
+	if !token.IsAnyOf(x...) {
+		r.fail(pos, x...)
+		return nil, pos, false
+	}
+
+	return []*ast.Node{ast.NewLeaf(ast.Kind(token.Type.String()), token)}, pos + 1, true
+}
+
+func (x anyExpr) match(r *run, pos int) ([]*ast.Node, int, bool) {
+	token := r.tokens.TokenAtPosition(pos)
+	if token.TypeIs(golex.TypeEof) {
+		r.fail(pos, golex.Token{Type: golex.AnyTokenType})
+		return nil, pos, false
+	}
+
+	return []*ast.Node{ast.NewLeaf(ast.Kind(token.Type.String()), token)}, pos + 1, true
+}
+
+func (x ruleExpr) match(r *run, pos int) ([]*ast.Node, int, bool) {
+	node, end, ok := r.apply(x.name, pos)
+	if !ok {
+		return nil, pos, false
+	}
+
+	return []*ast.Node{node}, end, true
+}
+
+func (x seqExpr) match(r *run, pos int) ([]*ast.Node, int, bool) {
+	nodes := []*ast.Node{}
+	end := pos
+
+	for _, expr := range x {
+		matched, next, ok := expr.match(r, end)
+		if !ok {
+			return nil, pos, false
+		}
+
+		nodes = append(nodes, matched...)
+		end = next
+	}
+
+	return nodes, end, true
+}
+
+func (x choiceExpr) match(r *run, pos int) ([]*ast.Node, int, bool) {
+	for _, expr := range x {
+		if nodes, end, ok := expr.match(r, pos); ok {
+			return nodes, end, true
+		}
+	}
+
+	return nil, pos, false
+}
+
+func (x repeatExpr) match(r *run, pos int) ([]*ast.Node, int, bool) {
+	nodes := []*ast.Node{}
+	end := pos
+
+	for count := 0; ; count++ {
+		matched, next, ok := x.expr.match(r, end)
+
+		// An expression that matches without consuming would match forever
+		if !ok || next == end {
+			return nodes, end, count >= x.min || ok
+		}
+
+		nodes = append(nodes, matched...)
+		end = next
+	}
+}
+
+func (x optionalExpr) match(r *run, pos int) ([]*ast.Node, int, bool) {
+	if nodes, end, ok := x.expr.match(r, pos); ok {
+		return nodes, end, true
+	}
+
+	return nil, pos, true
+}
+
+func (x predicateExpr) match(r *run, pos int) ([]*ast.Node, int, bool) {
+	if !x.negate {
+		_, _, ok := x.expr.match(r, pos)
+		return nil, pos, ok
+	}
+
+	// What a not predicate expected is not what the input should contain
+	r.predicates += 1
+	_, _, ok := x.expr.match(r, pos)
+	r.predicates -= 1
+
+	if ok {
+		r.fail(pos)
+	}
+
+	return nil, pos, !ok
+}
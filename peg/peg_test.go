@@ -0,0 +1,205 @@
+package peg
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cornejong/golex"
+	"github.com/cornejong/golex/ast"
+	"github.com/cornejong/golex/internal/golextest"
+	"github.com/cornejong/golex/parse"
+)
+
+// counted counts how often the expression is matched
+type counted struct {
+	expr  Expression
+	count *int
+}
+
+func (x counted) match(r *run, pos int) ([]*ast.Node, int, bool) {
+	*x.count += 1
+	return x.expr.match(r, pos)
+}
+
+func TestPegLeftRecursion(t *testing.T) {
+	fmt.Println("TestPegLeftRecursion...")
+
+	g := NewGrammar().
+		Define("Sum", Choice(Seq(Rule("Sum"), Type(golex.TypePlus, golex.TypeMinus), Rule("Product")), Rule("Product"))).
+		Define("Product", Choice(Seq(Rule("Product"), Type(golex.TypeMultiply), Rule("Value")), Rule("Value"))).
+		Define("Value", Choice(Type(golex.TypeInteger), Seq(Literal("("), Rule("Sum"), Literal(")"))))
+
+	tree, err := NewParser(g).Parse("Sum", golextest.Tokenize(t, "1 - 2 - 3 * 4 * (5)"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	differ := &golex.Differ{}
+	differ.Compare(
+		`(Sum (Sum (Sum (Product (Value (Integer "1")))) (Minus "-") (Product (Value (Integer "2")))) (Minus "-")`+
+			` (Product (Product (Product (Value (Integer "3"))) (Multiply "*") (Value (Integer "4"))) (Multiply "*")`+
+			` (Value (OpenParenthesis "(") (Sum (Product (Value (Integer "5")))) (CloseParenthesis ")"))))`,
+		ast.SExpr(tree),
+	)
+
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+
+	// Parents are the nodes of the final tree, not the ones discarded while growing the seed
+	ast.Inspect(tree, func(node *ast.Node) bool {
+		if node == nil {
+			return false
+		}
+
+		for _, child := range node.Children {
+			if child.Parent != node {
+				t.Errorf("Expected %s to be the parent of %s", node.Kind, child.Kind)
+			}
+		}
+
+		return true
+	})
+
+	// Indirect left recursion
+	g = NewGrammar().
+		Define("Expr", Choice(Rule("Call"), Rule("Name"))).
+		Define("Call", Seq(Rule("Expr"), Literal("("), Literal(")"))).
+		Define("Name", Type(golex.TypeSymbol))
+
+	tree, err = NewParser(g).Parse("Expr", golextest.Tokenize(t, "f()()"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	differ.Compare(
+		`(Expr (Call (Expr (Call (Expr (Name (Symbol "f"))) (OpenParenthesis "(") (CloseParenthesis ")"))) (OpenParenthesis "(") (CloseParenthesis ")")))`,
+		ast.SExpr(tree),
+	)
+
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+}
+
+func TestPegPredicates(t *testing.T) {
+	fmt.Println("TestPegPredicates...")
+
+	g := NewGrammar().
+		Define("Statements", OneOrMore(Rule("Statement"))).
+		Define("Statement", Seq(Choice(Rule("Let"), Rule("Expression")), Literal(";"))).
+		Define("Let", Seq(Literal("let"), Rule("Identifier"), Literal("="), Rule("Expression"))).
+		Define("Identifier", Seq(Not(Literal("let")), Type(golex.TypeSymbol))).
+		// Skip anything up to the semicolon, as long as it starts with a value
+		Define("Expression", Seq(And(Type(golex.TypeSymbol, golex.TypeInteger)), OneOrMore(Seq(Not(Literal(";")), Any()))))
+
+	tree, err := NewParser(g).Parse("Statements", golextest.Tokenize(t, "let x = 1 + 2; x * 3;"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	differ := &golex.Differ{}
+	differ.Compare(
+		`(Statements (Statement (Let (Symbol "let") (Identifier (Symbol "x")) (Assign "=") (Expression (Integer "1") (Plus "+") (Integer "2"))) (Semicolon ";"))`+
+			` (Statement (Expression (Symbol "x") (Multiply "*") (Integer "3")) (Semicolon ";")))`,
+		ast.SExpr(tree),
+	)
+
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+
+	_, err = NewParser(g).Parse("Let", golextest.Tokenize(t, "let let = 1"))
+
+	var parseErr *parse.Error
+	if !errors.As(err, &parseErr) || err.Error() != "1:5: unexpected symbol 'let'" {
+		t.Errorf("Expected let not to be an identifier but got %v", err)
+	}
+
+	if _, err := NewParser(g).Parse("Statements", golextest.Tokenize(t, "x")); err == nil || err.Error() != "1:2: expected any token or ';' but found end of file" {
+		t.Errorf("Expected a missing semicolon but got %v", err)
+	}
+}
+
+func TestPegMemoisation(t *testing.T) {
+	fmt.Println("TestPegMemoisation...")
+
+	count := 0
+	value := counted{expr: Choice(Type(golex.TypeInteger), Seq(Literal("("), Rule("List"), Literal(")"))), count: &count}
+
+	// Every alternative starts with the same list, which would be parsed again for each of them without memoisation
+	g := NewGrammar().
+		Define("List", Choice(
+			Seq(Rule("Values"), Rule("Semicolon")),
+			Seq(Rule("Values"), Rule("Comma")),
+			Rule("Values"),
+		)).
+		Define("Values", OneOrMore(Rule("Value"))).
+		Define("Semicolon", Literal(";")).
+		Define("Comma", Literal(",")).
+		Define("Value", value)
+
+	source := "1 (2 (3 (4)) 5) 6"
+	if _, err := NewParser(g).Parse("List", golextest.Tokenize(t, source)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The 9 values are parsed once, plus a failed attempt at the end of each of the 4 lists
+	if count != 13 {
+		t.Errorf("Expected the values to be parsed 13 times but got %d", count)
+	}
+
+	// Storing the failed separators drops the memoised values from the bounded memo table
+	count = 0
+	tokens := golextest.Collection(t, source)
+	tree, err := NewParser(g, WithMemoLimit(2)).Match("List", tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count <= 13 || tree.Span.End.Col != 18 || !tokens.ReachedEOF() {
+		t.Errorf("Expected the bounded memo table to parse values again but got %d parses", count)
+	}
+}
+
+func TestPegErrors(t *testing.T) {
+	fmt.Println("TestPegErrors...")
+
+	g := NewGrammar().
+		Define("Call", Seq(Type(golex.TypeSymbol), Literal("("), Optional(Rule("Args")), Literal(")"))).
+		Define("Args", Seq(Rule("Arg"), ZeroOrMore(Seq(Literal(","), Rule("Arg"))))).
+		Define("Arg", Type(golex.TypeInteger, golex.TypeSymbol))
+
+	_, err := NewParser(g).Parse("Call", golextest.Tokenize(t, "f(1, 2 3)"))
+	if !errors.Is(err, golex.ErrUnexpectedToken) || err.Error() != "1:8: expected ',' or ')' but found integer '3'" {
+		t.Errorf("Expected an unexpected token error but got %v", err)
+	}
+
+	_, err = NewParser(g).Parse("Call", golextest.Tokenize(t, "f(1) g"))
+	if err == nil || err.Error() != "1:6: expected end of file but found symbol 'g'" {
+		t.Errorf("Expected the tokens to be parsed up to the end but got %v", err)
+	}
+
+	tokens := golextest.Collection(t, "f(1) g")
+	if _, err := NewParser(g).Match("Call", tokens); err != nil || tokens.Peek(1).Literal != "g" {
+		t.Errorf("Expected to match up to g but got %v", err)
+	}
+
+	g.Define("Arg", Choice(Type(golex.TypeInteger), Rule("Expression")))
+	if _, err := NewParser(g).Parse("Call", golextest.Tokenize(t, "f()")); !errors.Is(err, golex.ErrUndefined) || err.Error() != "undefined rule Expression in rule Arg" {
+		t.Errorf("Expected an undefined rule error but got %v", err)
+	}
+	// Patterns are deduplicated on their type and literal, their values may not be comparable
+	pattern := golex.Token{Type: golex.TypeSymbol, Value: []string{"name"}}
+	g = NewGrammar().Define("Name", Choice(Token(pattern), Token(pattern)))
+	_, err = NewParser(g).Parse("Name", golextest.Tokenize(t, "1"))
+
+	var parseErr *parse.Error
+	if !errors.As(err, &parseErr) || len(parseErr.Expected) != 1 {
+		t.Errorf("Expected a single expected symbol but got %v", err)
+	}
+}
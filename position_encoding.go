@@ -0,0 +1,99 @@
+package golex
+
+import (
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// utf8Length returns the number of bytes needed to encode the runes in UTF-8
+func utf8Length(runes []rune) int {
+	length := 0
+	for _, r := range runes {
+		if size := utf8.RuneLen(r); size > 0 {
+			length += size
+		} else {
+			length += utf8.RuneLen(utf8.RuneError)
+		}
+	}
+
+	return length
+}
+
+// utf16Length returns the number of code units needed to encode the runes in UTF-16
+func utf16Length(runes []rune) int {
+	length := 0
+	for _, r := range runes {
+		if size := utf16.RuneLen(r); size > 0 {
+			length += size
+		} else {
+			length += 1 // invalid runes are encoded as U+FFFD, which is a single code unit
+		}
+	}
+
+	return length
+}
+
+// RuneOffsetToByteOffset converts a rune offset, as used by Position.Cursor, to a byte offset in the source
+func RuneOffsetToByteOffset(source string, runeOffset int) int {
+	i := 0
+	for byteOffset := range source {
+		if i == runeOffset {
+			return byteOffset
+		}
+
+		i++
+	}
+
+	return len(source)
+}
+
+// ByteOffsetToRuneOffset converts a byte offset in the source to a rune offset, as used by Position.Cursor
+func ByteOffsetToRuneOffset(source string, byteOffset int) int {
+	byteOffset = min(max(byteOffset, 0), len(source))
+	return utf8.RuneCountInString(source[:byteOffset])
+}
+
+// RuneColumnToUTF16Column converts a 1-based rune column of the line to a 1-based UTF-16 code unit column
+func RuneColumnToUTF16Column(line string, col int) int {
+	runes := []rune(line)
+	return utf16Length(runes[:min(max(col-1, 0), len(runes))]) + 1
+}
+
+// UTF16ColumnToRuneColumn converts a 1-based UTF-16 code unit column of the line to a 1-based rune column
+func UTF16ColumnToRuneColumn(line string, utf16Col int) int {
+	units := 0
+	col := 1
+	for _, r := range line {
+		if units >= utf16Col-1 {
+			break
+		}
+
+		units += utf16Length([]rune{r})
+		col++
+	}
+
+	return col
+}
+
+// RuneColumnToByteColumn converts a 1-based rune column of the line to a 1-based byte column
+func RuneColumnToByteColumn(line string, col int) int {
+	return RuneOffsetToByteOffset(line, col-1) + 1
+}
+
+// ByteColumnToRuneColumn converts a 1-based byte column of the line to a 1-based rune column
+func ByteColumnToRuneColumn(line string, byteCol int) int {
+	return ByteOffsetToRuneOffset(line, byteCol-1) + 1
+}
+
+// PositionWithEncodings returns the position of the rune offset in the source
+// including the byte offset and the UTF-16 column
+func PositionWithEncodings(source string, runeOffset int) Position {
+	runes := []rune(source)
+	position := PositionFromOffset(runes, runeOffset)
+
+	lineStart := position.Cursor - (position.Col - 1)
+	position.Offset = utf8Length(runes[:position.Cursor])
+	position.UTF16Col = utf16Length(runes[lineStart:position.Cursor]) + 1
+
+	return position
+}
@@ -0,0 +1,258 @@
+// Package pratt provides a Pratt (precedence climbing) expression parser on top of the golex lexer.
+//
+// A Grammar registers prefix and infix handlers per token type, with binding powers
+// and associativity. The handlers build user-defined nodes of type N.
+// Tokens are consumed through the NextToken and Lookahead methods of the lexer.
+package pratt
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cornejong/golex"
+)
+
+// ErrInvalidPower is returned by Parse when an infix rule was registered with a binding power below 1
+var ErrInvalidPower = errors.New("invalid binding power")
+
+// TokenSource is the token input of the parser, it is implemented by *golex.Lexer
+type TokenSource interface {
+	NextToken() (golex.Token, error)
+	Lookahead(offset int) golex.Token
+}
+
+// Associativity determines how infix operators of the same binding power group
+type Associativity int
+
+const (
+	// Left groups a - b - c as (a - b) - c
+	Left Associativity = iota
+	// Right groups a ^ b ^ c as a ^ (b ^ c)
+	Right
+)
+
+// PrefixFunc parses an expression starting with the token, like literals, prefix operators and groups
+type PrefixFunc[N any] func(p *Parser[N], token golex.Token) (N, error)
+
+// InfixFunc parses an expression continuing the left expression with the token, like binary operators and calls
+type InfixFunc[N any] func(p *Parser[N], left N, token golex.Token) (N, error)
+
+type prefixRule[N any] struct {
+	power   int
+	handler PrefixFunc[N]
+}
+
+type infixRule[N any] struct {
+	power         int
+	associativity Associativity
+	handler       InfixFunc[N]
+}
+
+// Grammar holds the prefix and infix rules of the expressions
+type Grammar[N any] struct {
+	prefix map[golex.TokenType]prefixRule[N]
+	infix  map[golex.TokenType]infixRule[N]
+	// err is the first invalid rule, returned by Parse
+	err error
+}
+
+// NewGrammar creates an empty grammar
+func NewGrammar[N any]() *Grammar[N] {
+	return &Grammar[N]{
+		prefix: map[golex.TokenType]prefixRule[N]{},
+		infix:  map[golex.TokenType]infixRule[N]{},
+	}
+}
+
+// Prefix registers a handler for expressions starting with the token type.
+// The power is the binding power of the operand parsed using Parser.Operand.
+func (g *Grammar[N]) Prefix(tokenType golex.TokenType, power int, handler PrefixFunc[N]) *Grammar[N] {
+	g.prefix[tokenType] = prefixRule[N]{power: power, handler: handler}
+	return g
+}
+
+// Infix registers a handler for expressions continuing with the token type.
+// The power is the binding power of the token towards its left operand,
+// operators with a higher power bind tighter. Parse starts at power 0, so the
+// power has to be at least 1, otherwise Err and Parse return ErrInvalidPower.
+func (g *Grammar[N]) Infix(tokenType golex.TokenType, power int, associativity Associativity, handler InfixFunc[N]) *Grammar[N] {
+	if power < 1 {
+		if g.err == nil {
+			g.err = fmt.Errorf("%w: the infix power of %s is %d, it has to be at least 1", ErrInvalidPower, golex.DisplayName(tokenType), power)
+		}
+
+		return g
+	}
+
+	g.infix[tokenType] = infixRule[N]{power: power, associativity: associativity, handler: handler}
+	return g
+}
+
+// Literal registers a prefix handler for tokens which are complete expressions on their own
+func (g *Grammar[N]) Literal(tokenType golex.TokenType, build func(token golex.Token) N) *Grammar[N] {
+	return g.Prefix(tokenType, 0, func(p *Parser[N], token golex.Token) (N, error) {
+		return build(token), nil
+	})
+}
+
+// Unary registers a prefix operator
+func (g *Grammar[N]) Unary(tokenType golex.TokenType, power int, build func(operator golex.Token, operand N) N) *Grammar[N] {
+	return g.Prefix(tokenType, power, func(p *Parser[N], operator golex.Token) (N, error) {
+		operand, err := p.Operand()
+		if err != nil {
+			return operand, err
+		}
+
+		return build(operator, operand), nil
+	})
+}
+
+// Binary registers an infix operator
+func (g *Grammar[N]) Binary(tokenType golex.TokenType, power int, associativity Associativity, build func(operator golex.Token, left N, right N) N) *Grammar[N] {
+	return g.Infix(tokenType, power, associativity, func(p *Parser[N], left N, operator golex.Token) (N, error) {
+		right, err := p.Operand()
+		if err != nil {
+			return right, err
+		}
+
+		return build(operator, left, right), nil
+	})
+}
+
+// Err returns the error of the first invalid rule registered with the grammar
+func (g *Grammar[N]) Err() error { return g.err }
+
+// Parse parses a single expression from the source, leaving the tokens after it
+func (g *Grammar[N]) Parse(source TokenSource) (N, error) {
+	if g.err != nil {
+		var zero N
+		return zero, g.err
+	}
+
+	p := &Parser[N]{grammar: g, source: source}
+	return p.Expression(0)
+}
+
+// ###################################################
+// #              Parser
+// ###################################################
+
+// Parser is a single run of a grammar over a token source, it is passed to the handlers
+type Parser[N any] struct {
+	grammar *Grammar[N]
+	source  TokenSource
+	// power is the binding power of the operand of the rule being handled
+	power int
+}
+
+// Source returns the token source of the parser
+func (p *Parser[N]) Source() TokenSource { return p.source }
+
+// Expression parses an expression of which all infix operators bind tighter than the power
+func (p *Parser[N]) Expression(power int) (N, error) {
+	var left N
+
+	token, err := p.source.NextToken()
+	if err != nil {
+		return left, err
+	}
+
+	prefix, ok := p.grammar.prefix[token.Type]
+	if !ok {
+		return left, p.unexpected(token, "an expression")
+	}
+
+	left, err = p.handle(prefix.power, func() (N, error) { return prefix.handler(p, token) })
+	if err != nil {
+		return left, err
+	}
+
+	for {
+		next := p.source.Lookahead(1)
+
+		infix, ok := p.grammar.infix[next.Type]
+		if !ok || infix.power <= power {
+			return left, nil
+		}
+
+		if _, err := p.source.NextToken(); err != nil {
+			return left, err
+		}
+
+		// Right associative operators accept operators of the same power in their right operand
+		operandPower := infix.power
+		if infix.associativity == Right {
+			operandPower -= 1
+		}
+
+		left, err = p.handle(operandPower, func() (N, error) { return infix.handler(p, left, next) })
+		if err != nil {
+			return left, err
+		}
+	}
+}
+
+// Operand parses the operand of the rule being handled using its binding power
+func (p *Parser[N]) Operand() (N, error) {
+	return p.Expression(p.power)
+}
+
+// Peek returns the next token without consuming it
+func (p *Parser[N]) Peek() golex.Token {
+	return p.source.Lookahead(1)
+}
+
+// Next consumes the next token
+func (p *Parser[N]) Next() (golex.Token, error) {
+	return p.source.NextToken()
+}
+
+// Accept consumes the next token if it is of any of the token types
+func (p *Parser[N]) Accept(types ...golex.TokenType) (golex.Token, bool, error) {
+	token := p.source.Lookahead(1)
+	if !token.TypeIsAnyOf(types...) {
+		return token, false, nil
+	}
+
+	token, err := p.source.NextToken()
+	return token, err == nil, err
+}
+
+// Expect consumes the next token if it is of the token type, otherwise it returns an ErrUnexpectedToken error
+func (p *Parser[N]) Expect(tokenType golex.TokenType) (golex.Token, error) {
+	token := p.source.Lookahead(1)
+	if !token.TypeIs(tokenType) {
+		return token, p.unexpected(token, golex.DisplayName(tokenType))
+	}
+
+	return p.source.NextToken()
+}
+
+// handle runs a handler with the binding power of its operand
+func (p *Parser[N]) handle(power int, handler func() (N, error)) (N, error) {
+	outer := p.power
+	p.power = power
+	defer func() { p.power = outer }()
+
+	return handler()
+}
+
+// errorSource is implemented by token sources which can create errors with the source snippet, like *golex.Lexer
+type errorSource interface {
+	ErrorAt(kind *golex.ErrorKind, message string, position golex.Position) *golex.Error
+}
+
+func (p *Parser[N]) unexpected(token golex.Token, expected string) error {
+	kind := golex.ErrUnexpectedToken
+	if token.TypeIs(golex.TypeEof) {
+		kind = golex.ErrUnexpectedEOF
+	}
+
+	message := fmt.Sprintf("expected %s but found %s", expected, golex.DisplayName(token.Type))
+
+	if source, ok := p.source.(errorSource); ok {
+		return source.ErrorAt(kind, message, token.Position)
+	}
+
+	return golex.NewErrorOfKind(kind, message, token.Position, nil)
+}
@@ -0,0 +1,140 @@
+package pratt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cornejong/golex"
+)
+
+func getGrammar() *Grammar[string] {
+	binary := func(operator golex.Token, left string, right string) string {
+		return fmt.Sprintf("(%s %s %s)", operator.Literal, left, right)
+	}
+
+	literal := func(token golex.Token) string { return token.Literal }
+
+	g := NewGrammar[string]().
+		Literal(golex.TypeInteger, literal).
+		Literal(golex.TypeSymbol, literal).
+		Binary(golex.TypePlus, 10, Left, binary).
+		Binary(golex.TypeMinus, 10, Left, binary).
+		Binary(golex.TypeMultiply, 20, Left, binary).
+		Binary(golex.TypeDivide, 20, Left, binary).
+		Binary(golex.TypeCaret, 40, Right, binary).
+		Unary(golex.TypeMinus, 30, func(operator golex.Token, operand string) string {
+			return fmt.Sprintf("(- %s)", operand)
+		})
+
+	// Grouping
+	g.Prefix(golex.TypeOpenParen, 0, func(p *Parser[string], token golex.Token) (string, error) {
+		expression, err := p.Expression(0)
+		if err != nil {
+			return expression, err
+		}
+
+		_, err = p.Expect(golex.TypeCloseParen)
+		return expression, err
+	})
+
+	// Calls
+	g.Infix(golex.TypeOpenParen, 50, Left, func(p *Parser[string], callee string, token golex.Token) (string, error) {
+		args := []string{"call", callee}
+
+		for {
+			if _, ok, err := p.Accept(golex.TypeCloseParen); ok || err != nil {
+				return "(" + strings.Join(args, " ") + ")", err
+			}
+
+			if len(args) > 2 {
+				if _, err := p.Expect(golex.TypeComma); err != nil {
+					return "", err
+				}
+			}
+
+			arg, err := p.Expression(0)
+			if err != nil {
+				return "", err
+			}
+
+			args = append(args, arg)
+		}
+	})
+
+	return g
+}
+
+func TestPrattParser(t *testing.T) {
+	fmt.Println("TestPrattParser...")
+
+	cases := map[string]string{
+		"1 + 2 * 3":             "(+ 1 (* 2 3))",
+		"a - b - c":             "(- (- a b) c)",
+		"a ^ b ^ c":             "(^ a (^ b c))",
+		"- a * b":               "(* (- a) b)",
+		"- a ^ b":               "(- (^ a b))",
+		"(1 + 2) * f(a, b + 1)": "(* (+ 1 2) (call f a (+ b 1)))",
+		"f()()":                 "(call (call f))",
+	}
+
+	g := getGrammar()
+	for source, expect := range cases {
+		lexer := golex.NewLexer()
+		lexer.TokenizeManual(source)
+
+		result, err := g.Parse(lexer)
+		if err != nil {
+			t.Errorf("Unexpected error parsing %q: %s", source, err)
+			continue
+		}
+
+		if result != expect {
+			t.Errorf("Expected %q to parse as %s but got %s", source, expect, result)
+		}
+	}
+
+	// The tokens after the expression are left
+	lexer := golex.NewLexer()
+	lexer.TokenizeManual("a + b; c")
+	if _, err := g.Parse(lexer); err != nil || !lexer.Lookahead(1).TypeIs(golex.TypeSemicolon) {
+		t.Errorf("Expected the parser to stop at the semicolon but got %s (%v)", lexer.Lookahead(1).Type, err)
+	}
+}
+
+func TestPrattParserErrors(t *testing.T) {
+	fmt.Println("TestPrattParserErrors...")
+
+	g := getGrammar()
+
+	lexer := golex.NewLexer()
+	lexer.TokenizeManual("1 + * 2")
+
+	_, err := g.Parse(lexer)
+
+	var lexErr *golex.Error
+	if !errors.Is(err, golex.ErrUnexpectedToken) || !errors.As(err, &lexErr) || lexErr.Position.Col != 5 {
+		t.Fatalf("Expected an unexpected token error at column 5 but got %v", err)
+	}
+
+	if lexErr.Message != "expected an expression but found '*'" || lexErr.Snippet != "1 + * 2" {
+		t.Errorf("Unexpected error message %q with snippet %q", lexErr.Message, lexErr.Snippet)
+	}
+
+	lexer.TokenizeManual("f(a")
+	if _, err := g.Parse(lexer); !errors.Is(err, golex.ErrUnexpectedEOF) {
+		t.Errorf("Expected an unexpected EOF error but got %v", err)
+	}
+
+	// Parse starts at power 0, an infix operator without a positive power would never apply
+	g = getGrammar().Binary(golex.TypeModulo, 0, Left, func(op golex.Token, l string, r string) string { return l })
+	if !errors.Is(g.Err(), ErrInvalidPower) || g.Err().Error() != "invalid binding power: the infix power of '%' is 0, it has to be at least 1" {
+		t.Errorf("Expected an invalid power error but got %v", g.Err())
+	}
+
+	lexer.TokenizeManual("1 % 2")
+	if _, err := g.Parse(lexer); !errors.Is(err, ErrInvalidPower) {
+		t.Errorf("Expected Parse to return the invalid power but got %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package golex
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding is the character encoding of a source
+type Encoding int
+
+const (
+	EncodingUTF8 Encoding = iota
+	EncodingUTF16LE
+	EncodingUTF16BE
+	EncodingUTF32LE
+	EncodingUTF32BE
+)
+
+func (e Encoding) String() string {
+	switch e {
+	case EncodingUTF8:
+		return "UTF-8"
+	case EncodingUTF16LE:
+		return "UTF-16LE"
+	case EncodingUTF16BE:
+		return "UTF-16BE"
+	case EncodingUTF32LE:
+		return "UTF-32LE"
+	case EncodingUTF32BE:
+		return "UTF-32BE"
+	}
+
+	return fmt.Sprintf("Encoding(%d)", int(e))
+}
+
+// DetectEncoding detects the encoding of the source from its byte-order mark.
+// It returns the encoding and the length of the byte-order mark in bytes.
+// Sources without a byte-order mark are UTF-8.
+func DetectEncoding(data []byte) (Encoding, int) {
+	return detectEncoding(string(data[:min(len(data), 4)]))
+}
+
+func detectEncoding(source string) (Encoding, int) {
+	// The UTF-32LE mark starts with the UTF-16LE mark, so it has to be checked first
+	switch {
+	case strings.HasPrefix(source, "\x00\x00\xfe\xff"):
+		return EncodingUTF32BE, 4
+	case strings.HasPrefix(source, "\xff\xfe\x00\x00"):
+		return EncodingUTF32LE, 4
+	case strings.HasPrefix(source, "\xfe\xff"):
+		return EncodingUTF16BE, 2
+	case strings.HasPrefix(source, "\xff\xfe"):
+		return EncodingUTF16LE, 2
+	case strings.HasPrefix(source, "\xef\xbb\xbf"):
+		return EncodingUTF8, 3
+	}
+
+	return EncodingUTF8, 0
+}
+
+// DecodeSource decodes the source to UTF-8, stripping the byte-order mark and transcoding UTF-16 and UTF-32.
+// Invalid sequences are replaced by U+FFFD and the first one is returned as an ErrInvalidEncoding error.
+func DecodeSource(data []byte) (string, Encoding, error) {
+	encoding, runes, invalid := decodeSource(string(data))
+	if len(invalid) == 0 {
+		return string(runes), encoding, nil
+	}
+
+	position := NewLineTable(runes).PositionOf(invalid[0])
+	return string(runes), encoding, NewErrorOfKind(ErrInvalidEncoding, fmt.Sprintf("Invalid %s sequence", encoding), position, runes)
+}
+
+// decodeSource decodes the source to runes, stripping the byte-order mark.
+// Invalid sequences are decoded as U+FFFD and their cursor offsets are returned.
+func decodeSource(source string) (Encoding, []rune, []int) {
+	encoding, bom := detectEncoding(source)
+	source = source[bom:]
+
+	switch encoding {
+	case EncodingUTF16LE, EncodingUTF16BE:
+		runes, invalid := decodeUTF16(source, encoding == EncodingUTF16BE)
+		return encoding, runes, invalid
+	case EncodingUTF32LE, EncodingUTF32BE:
+		runes, invalid := decodeUTF32(source, encoding == EncodingUTF32BE)
+		return encoding, runes, invalid
+	}
+
+	if utf8.ValidString(source) {
+		return encoding, []rune(source), nil
+	}
+
+	runes := make([]rune, 0, len(source))
+	invalid := []int{}
+	for len(source) > 0 {
+		r, size := utf8.DecodeRuneInString(source)
+		if r == utf8.RuneError && size == 1 {
+			invalid = append(invalid, len(runes))
+		}
+
+		runes = append(runes, r)
+		source = source[size:]
+	}
+
+	return encoding, runes, invalid
+}
+
+func decodeUTF16(source string, bigEndian bool) ([]rune, []int) {
+	unit := func(i int) rune {
+		if bigEndian {
+			return rune(source[i])<<8 | rune(source[i+1])
+		}
+
+		return rune(source[i+1])<<8 | rune(source[i])
+	}
+
+	runes := make([]rune, 0, len(source)/2)
+	invalid := []int{}
+
+	i := 0
+	for ; i+1 < len(source); i += 2 {
+		r := unit(i)
+		if utf16.IsSurrogate(r) {
+			if i+3 < len(source) {
+				if pair := utf16.DecodeRune(r, unit(i+2)); pair != utf8.RuneError {
+					runes = append(runes, pair)
+					i += 2
+					continue
+				}
+			}
+
+			invalid = append(invalid, len(runes))
+			r = utf8.RuneError
+		}
+
+		runes = append(runes, r)
+	}
+
+	// A trailing odd byte is a truncated code unit
+	if i < len(source) {
+		invalid = append(invalid, len(runes))
+		runes = append(runes, utf8.RuneError)
+	}
+
+	return runes, invalid
+}
+
+func decodeUTF32(source string, bigEndian bool) ([]rune, []int) {
+	runes := make([]rune, 0, len(source)/4)
+	invalid := []int{}
+
+	i := 0
+	for ; i+3 < len(source); i += 4 {
+		var r rune
+		if bigEndian {
+			r = rune(source[i])<<24 | rune(source[i+1])<<16 | rune(source[i+2])<<8 | rune(source[i+3])
+		} else {
+			r = rune(source[i+3])<<24 | rune(source[i+2])<<16 | rune(source[i+1])<<8 | rune(source[i])
+		}
+
+		if !utf8.ValidRune(r) {
+			invalid = append(invalid, len(runes))
+			r = utf8.RuneError
+		}
+
+		runes = append(runes, r)
+	}
+
+	// Trailing bytes are a truncated code unit
+	if i < len(source) {
+		invalid = append(invalid, len(runes))
+		runes = append(runes, utf8.RuneError)
+	}
+
+	return runes, invalid
+}
@@ -0,0 +1,96 @@
+package golex
+
+import "fmt"
+
+// Span represents the range of the source from Start up to, but not including, End
+type Span struct {
+	Start Position
+	End   Position
+}
+
+func NewSpan(start Position, end Position) Span {
+	return Span{Start: start, End: end}
+}
+
+// Span returns the span of the source covered by the token
+func (t Token) Span() Span {
+	return Span{Start: t.Position, End: t.End}
+}
+
+func (s Span) String() string {
+	return fmt.Sprintf("%d:%d-%d:%d", s.Start.Row, s.Start.Col, s.End.Row, s.End.Col)
+}
+
+// Len returns the number of characters covered by the span
+func (s Span) Len() int {
+	return s.End.Cursor - s.Start.Cursor
+}
+
+// IsEmpty checks if the span covers no characters
+func (s Span) IsEmpty() bool {
+	return s.Len() <= 0
+}
+
+// Contains checks if the position lies within the span
+func (s Span) Contains(pos Position) bool {
+	return s.ContainsOffset(pos.Cursor)
+}
+
+// ContainsOffset checks if the cursor offset lies within the span
+func (s Span) ContainsOffset(offset int) bool {
+	return offset >= s.Start.Cursor && offset < s.End.Cursor
+}
+
+// ContainsSpan checks if the other span lies completely within the span
+func (s Span) ContainsSpan(other Span) bool {
+	return other.Start.Cursor >= s.Start.Cursor && other.End.Cursor <= s.End.Cursor
+}
+
+// Overlaps checks if the spans share at least one character
+func (s Span) Overlaps(other Span) bool {
+	return s.Start.Cursor < other.End.Cursor && other.Start.Cursor < s.End.Cursor
+}
+
+// Union returns the smallest span covering both spans
+func (s Span) Union(other Span) Span {
+	union := s
+	if other.Start.Cursor < union.Start.Cursor {
+		union.Start = other.Start
+	}
+
+	if other.End.Cursor > union.End.Cursor {
+		union.End = other.End
+	}
+
+	return union
+}
+
+// Text returns the part of the source covered by the span
+func (s Span) Text(source []rune) string {
+	start := min(max(s.Start.Cursor, 0), len(source))
+	end := min(max(s.End.Cursor, start), len(source))
+	return string(source[start:end])
+}
+
+// PositionFromOffset returns the position of the cursor offset within the source, only scanning
+// the source up to the offset. When converting multiple offsets of the same source use a LineTable instead.
+func PositionFromOffset(source []rune, offset int) Position {
+	offset = min(max(offset, 0), len(source))
+	row, lineStart := 1, 0
+
+	for i := 0; i < offset; i++ {
+		// An offset within a "\r\n" belongs to the line it terminates
+		if length := NewlinesUnicode.Length(source, i); length > 0 && i+length <= offset {
+			row, lineStart = row+1, i+length
+			i += length - 1
+		}
+	}
+
+	return Position{Row: row, Col: offset - lineStart + 1, Cursor: offset}
+}
+
+// SpanFromOffsets returns the span of the source between the cursor offsets.
+// When converting multiple spans of the same source use a LineTable instead.
+func SpanFromOffsets(source []rune, start int, end int) Span {
+	return Span{Start: PositionFromOffset(source, start), End: PositionFromOffset(source, end)}
+}
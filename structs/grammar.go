@@ -0,0 +1,543 @@
+// Package structs parses golex tokens straight into Go structs whose grammar is declared in field tags.
+//
+// Every field with a tag matches the expression of its tag, in the order of the fields:
+//
+//	type Call struct {
+//		Name string  `parser:"@Symbol"`
+//		Args []*Expr `parser:"'(' ( @@ ( ',' @@ )* )? ')'"`
+//	}
+//
+// The tag can also be the expression itself, like `@Symbol`, but go vet reports such tags.
+// The expressions use the following notation:
+//
+//	@@          parses the type of the field, a struct, a pointer to a struct or a union interface
+//	@x          captures the tokens matched by x into the field
+//	"lit" 'lit' matches a token with the literal, like Token.Is with golex.AnyTokenType
+//	Name        matches a token of the registered token type, like Integer or Symbol
+//	( … )       groups an expression
+//	a | b       matches a or b, the first alternative that matches is used
+//	x* x+ x?    matches x zero or more times, one or more times or optionally
+//
+// Captured tokens are converted to the type of the field. Strings concatenate the values of string
+// tokens and the literals of the other tokens, numbers are parsed from the literals, booleans are set
+// to true and slices append a value per token. Types implementing Capturer convert the tokens themselves.
+// Fields named Pos and EndPos of the type golex.Position are set to the start and end of the struct.
+//
+// An interface is a union of the types registered using WithUnion, they are tried in order.
+// Left recursive types would recurse forever, NewParser returns ErrInvalidGrammar for them.
+package structs
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/cornejong/golex"
+)
+
+// ErrInvalidGrammar is returned for types whose tags do not form a valid grammar
+var ErrInvalidGrammar = errors.New("invalid grammar")
+
+// Capturer is implemented by field types that convert the captured tokens themselves
+type Capturer interface {
+	Capture(tokens []golex.Token) error
+}
+
+var (
+	positionType   = reflect.TypeFor[golex.Position]()
+	tokenValueType = reflect.TypeFor[golex.Token]()
+	capturerType   = reflect.TypeFor[Capturer]()
+)
+
+// keyedTag matches conventional struct tags like `json:"name"`, other tags are the grammar themselves
+var keyedTag = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*:"`)
+
+// structGrammar is the compiled grammar of a struct type
+type structGrammar struct {
+	typ    reflect.Type
+	expr   node
+	pos    int
+	endPos int
+}
+
+// ###################################################
+// #              Expressions
+// ###################################################
+
+// node is a compiled expression, matching tokens into the struct value v
+type node interface {
+	match(r *run, v reflect.Value) bool
+}
+
+type (
+	sequence    []node
+	alternative []node
+
+	repetition struct {
+		node     node
+		min      int
+		optional bool
+	}
+
+	// terminal matches a single token using Token.Is
+	terminal struct {
+		pattern golex.Token
+	}
+
+	// capture stores the tokens matched by the node in the field
+	capture struct {
+		field int
+		node  node
+	}
+
+	// structCapture parses the type of the field
+	structCapture struct {
+		field int
+	}
+)
+
+// ###################################################
+// #              Compilation
+// ###################################################
+
+// compile compiles the grammar of the struct type and the types it refers to
+func (p *parser) compile(t reflect.Type) (*structGrammar, error) {
+	if g, ok := p.structs[t]; ok {
+		return g, nil
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %s is not a struct", ErrInvalidGrammar, t)
+	}
+
+	// The grammar is registered before compiling the fields, so types can refer to themselves
+	g := &structGrammar{typ: t, pos: -1, endPos: -1}
+	p.structs[t] = g
+
+	expr := sequence{}
+	for i := range t.NumField() {
+		field := t.Field(i)
+
+		if field.Type == positionType && (field.Name == "Pos" || field.Name == "EndPos") {
+			if field.Name == "Pos" {
+				g.pos = i
+			} else {
+				g.endPos = i
+			}
+
+			continue
+		}
+
+		tag, ok := field.Tag.Lookup("parser")
+		if !ok && !keyedTag.MatchString(string(field.Tag)) {
+			tag = string(field.Tag)
+		}
+
+		if tag == "" {
+			continue
+		}
+
+		if !field.IsExported() {
+			return nil, fmt.Errorf("%w: %s.%s is not exported", ErrInvalidGrammar, t, field.Name)
+		}
+
+		n, err := p.compileTag(t, i, tag)
+		if err != nil {
+			return nil, err
+		}
+
+		expr = append(expr, n)
+	}
+
+	g.expr = expr
+
+	return g, nil
+}
+
+// compileTag parses the expression of the tag of the field
+func (p *parser) compileTag(t reflect.Type, field int, tag string) (node, error) {
+	name := fmt.Sprintf("%s.%s", t, t.Field(field).Name)
+
+	lexer := golex.NewLexer()
+	tokens := []golex.Token{}
+	for token, err := range lexer.IterateNamed(name, tag) {
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidGrammar, err)
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	c := &tagCompiler{parser: p, lexer: lexer, typ: t, field: field, tokens: golex.NewTokenCollection(tokens)}
+
+	n, err := c.expression()
+	if err == nil && !c.tokens.Peek(1).TypeIs(golex.TypeEof) {
+		err = c.unexpected()
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidGrammar, err)
+	}
+
+	return n, nil
+}
+
+// tagCompiler is a recursive-descent parser of the expression of a tag
+type tagCompiler struct {
+	parser *parser
+	lexer  *golex.Lexer
+	typ    reflect.Type
+	field  int
+	tokens golex.TokenCollection
+}
+
+func (c *tagCompiler) unexpected() error {
+	token := c.tokens.Peek(1)
+	if token.TypeIs(golex.TypeEof) {
+		return c.lexer.ErrorAt(golex.ErrUnexpectedEOF, "unexpected end of the expression", token.Position)
+	}
+
+	return c.lexer.ErrorAt(golex.ErrUnexpectedToken, fmt.Sprintf("unexpected %s", golex.DisplayName(token.Type)), token.Position)
+}
+
+// expression = sequence { "|" sequence }
+func (c *tagCompiler) expression() (node, error) {
+	alternatives := alternative{}
+
+	for {
+		n, err := c.sequence()
+		if err != nil {
+			return nil, err
+		}
+
+		alternatives = append(alternatives, n)
+
+		if _, ok := c.tokens.Accept(golex.TypePipe); !ok {
+			break
+		}
+	}
+
+	if len(alternatives) == 1 {
+		return alternatives[0], nil
+	}
+
+	return alternatives, nil
+}
+
+// sequence = term { term }
+func (c *tagCompiler) sequence() (node, error) {
+	terms := sequence{}
+
+	for !c.tokens.Peek(1).TypeIsAnyOf(golex.TypePipe, golex.TypeCloseParen, golex.TypeEof) {
+		n, err := c.term()
+		if err != nil {
+			return nil, err
+		}
+
+		terms = append(terms, n)
+	}
+
+	if len(terms) == 0 {
+		return nil, c.unexpected()
+	}
+
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+
+	return terms, nil
+}
+
+// term = atom [ "*" | "+" | "?" ]
+func (c *tagCompiler) term() (node, error) {
+	n, err := c.atom()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case c.accept(golex.TypeMultiply):
+		return &repetition{node: n}, nil
+	case c.accept(golex.TypePlus):
+		return &repetition{node: n, min: 1}, nil
+	case c.accept(golex.TypeQuestionMark):
+		return &repetition{node: n, optional: true}, nil
+	}
+
+	return n, nil
+}
+
+// atom = "@" "@" | "@" atom | string | Name | "(" expression ")"
+func (c *tagCompiler) atom() (node, error) {
+	next := c.tokens.Peek(1)
+
+	switch {
+	case next.TypeIs(golex.TypeAt):
+		c.tokens.Advance()
+
+		if c.accept(golex.TypeAt) {
+			return &structCapture{field: c.field}, c.checkStructCapture(next)
+		}
+
+		n, err := c.atom()
+		if err != nil {
+			return nil, err
+		}
+
+		return &capture{field: c.field, node: n}, c.checkCapture(next)
+
+	case next.TypeIsAnyOf(golex.TypeDoubleQuoteString, golex.TypeSingleQuoteString):
+		c.tokens.Advance()
+
+		literal, _ := next.Value.(string)
+		if literal == "" {
+			return nil, c.lexer.ErrorAt(golex.ErrUnexpectedToken, "empty literal", next.Position)
+		}
+
+		return &terminal{pattern: golex.Token{Type: golex.AnyTokenType, Literal: literal}}, nil
+
+	case next.TypeIs(golex.TypeSymbol):
+		c.tokens.Advance()
+
+		tokenType, ok := golex.LookupTokenType(next.Literal)
+		if !ok {
+			return nil, c.lexer.ErrorAt(golex.ErrUnexpectedToken, fmt.Sprintf("unknown token type %s", next.Literal), next.Position)
+		}
+
+		return &terminal{pattern: golex.Token{Type: tokenType}}, nil
+
+	case next.TypeIs(golex.TypeOpenParen):
+		c.tokens.Advance()
+
+		n, err := c.expression()
+		if err != nil {
+			return nil, err
+		}
+
+		if !c.accept(golex.TypeCloseParen) {
+			return nil, c.unexpected()
+		}
+
+		return n, nil
+	}
+
+	return nil, c.unexpected()
+}
+
+func (c *tagCompiler) accept(tokenType golex.TokenType) bool {
+	_, ok := c.tokens.Accept(tokenType)
+	return ok
+}
+
+// checkStructCapture checks that the field can be parsed by @@, compiling the struct types it refers to
+func (c *tagCompiler) checkStructCapture(at golex.Token) error {
+	t := c.typ.Field(c.field).Type
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	pointer := t.Kind() == reflect.Pointer
+	if pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t.Kind() == reflect.Struct:
+		_, err := c.parser.compile(t)
+		return err
+
+	case t.Kind() == reflect.Interface && pointer:
+		return c.lexer.ErrorAt(golex.ErrUnexpectedToken, fmt.Sprintf("@@ cannot parse into a pointer to the union %s, use %s itself", t, t), at.Position)
+
+	case t.Kind() == reflect.Interface:
+		if len(c.parser.unions[t]) == 0 {
+			return c.lexer.ErrorAt(golex.ErrUnexpectedToken, fmt.Sprintf("%s has no members, register them using WithUnion", t), at.Position)
+		}
+
+		return nil
+	}
+
+	return c.lexer.ErrorAt(golex.ErrUnexpectedToken, fmt.Sprintf("@@ cannot parse into %s", t), at.Position)
+}
+
+// checkCapture checks that tokens can be captured into the field
+func (c *tagCompiler) checkCapture(at golex.Token) error {
+	t := c.typ.Field(c.field).Type
+	if capturable(t) || (t.Kind() == reflect.Slice && capturable(t.Elem())) {
+		return nil
+	}
+
+	return c.lexer.ErrorAt(golex.ErrUnexpectedToken, fmt.Sprintf("cannot capture tokens into %s", t), at.Position)
+}
+
+func capturable(t reflect.Type) bool {
+	if reflect.PointerTo(t).Implements(capturerType) || t == tokenValueType {
+		return true
+	}
+
+	if t.Kind() == reflect.Pointer {
+		return capturable(t.Elem())
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
+}
+
+// ###################################################
+// #              Left Recursion
+// ###################################################
+
+// targets returns the struct types parsed by @@ into a field of the type
+func (p *parser) targets(t reflect.Type) []reflect.Type {
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Interface {
+		return []reflect.Type{t}
+	}
+
+	types := []reflect.Type{}
+	for _, member := range p.unions[t] {
+		if member.Kind() == reflect.Pointer {
+			member = member.Elem()
+		}
+
+		types = append(types, member)
+	}
+
+	return types
+}
+
+// nullable returns the struct types which can be parsed without consuming a token
+func (p *parser) nullable() map[reflect.Type]bool {
+	nullable := map[reflect.Type]bool{}
+
+	for changed := true; changed; {
+		changed = false
+		for t, g := range p.structs {
+			if !nullable[t] && p.isNullable(t, g.expr, nullable) {
+				nullable[t] = true
+				changed = true
+			}
+		}
+	}
+
+	return nullable
+}
+
+func (p *parser) isNullable(t reflect.Type, n node, nullable map[reflect.Type]bool) bool {
+	switch x := n.(type) {
+	case sequence:
+		for _, n := range x {
+			if !p.isNullable(t, n, nullable) {
+				return false
+			}
+		}
+
+		return true
+	case alternative:
+		return slices.ContainsFunc(x, func(n node) bool { return p.isNullable(t, n, nullable) })
+	case *repetition:
+		return x.optional || x.min == 0 || p.isNullable(t, x.node, nullable)
+	case *capture:
+		return p.isNullable(t, x.node, nullable)
+	case *structCapture:
+		return slices.ContainsFunc(p.targets(t.Field(x.field).Type), func(target reflect.Type) bool { return nullable[target] })
+	}
+
+	return false
+}
+
+// leftmost appends the struct types which can be parsed by the node of the type t without consuming a token
+func (p *parser) leftmost(t reflect.Type, n node, nullable map[reflect.Type]bool, types []reflect.Type) []reflect.Type {
+	switch x := n.(type) {
+	case sequence:
+		for _, n := range x {
+			types = p.leftmost(t, n, nullable, types)
+			if !p.isNullable(t, n, nullable) {
+				break
+			}
+		}
+	case alternative:
+		for _, n := range x {
+			types = p.leftmost(t, n, nullable, types)
+		}
+	case *repetition:
+		types = p.leftmost(t, x.node, nullable, types)
+	case *capture:
+		types = p.leftmost(t, x.node, nullable, types)
+	case *structCapture:
+		types = append(types, p.targets(t.Field(x.field).Type)...)
+	}
+
+	return types
+}
+
+// leftRecursion returns an error for the first cycle of struct types that can be entered without consuming a token
+func (p *parser) leftRecursion() error {
+	nullable := p.nullable()
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := map[reflect.Type]int{}
+	path := []reflect.Type{}
+
+	var visit func(t reflect.Type) error
+	visit = func(t reflect.Type) error {
+		state[t] = visiting
+		path = append(path, t)
+
+		for _, next := range p.leftmost(t, p.structs[t].expr, nullable, nil) {
+			switch state[next] {
+			case unvisited:
+				if err := visit(next); err != nil {
+					return err
+				}
+			case visiting:
+				cycle := []string{}
+				for _, step := range append(slices.Clone(path[slices.Index(path, next):]), next) {
+					cycle = append(cycle, step.String())
+				}
+
+				return fmt.Errorf("%w: left recursion %s", ErrInvalidGrammar, strings.Join(cycle, " → "))
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[t] = visited
+
+		return nil
+	}
+
+	// The types are visited in a fixed order, so the same cycle is reported every time
+	types := slices.SortedFunc(maps.Keys(p.structs), func(a reflect.Type, b reflect.Type) int { return strings.Compare(a.String(), b.String()) })
+	for _, t := range types {
+		if state[t] == unvisited {
+			if err := visit(t); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
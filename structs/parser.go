@@ -0,0 +1,422 @@
+package structs
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/cornejong/golex"
+	"github.com/cornejong/golex/parse"
+)
+
+// Parser parses tokens into values of the struct type T
+type Parser[T any] struct {
+	parser
+	grammar *structGrammar
+}
+
+// parser holds the compiled grammars of the struct types, shared by all the instances of Parser
+type parser struct {
+	structs      map[reflect.Type]*structGrammar
+	unions       map[reflect.Type][]reflect.Type
+	lexerOptions []golex.LexerOptionFunc
+	err          error
+}
+
+// ParserOptionFunc registers unions and lexer options with the struct parser
+type ParserOptionFunc func(p *parser)
+
+// WithUnion registers the members of the interface I, which are tried in order when a field of the type I is parsed.
+// The members are values of struct types or pointers to struct types implementing I, like WithUnion[Value](&Number{}, &String{}).
+func WithUnion[I any](members ...I) ParserOptionFunc {
+	return ParserOptionFunc(func(p *parser) {
+		union := reflect.TypeFor[I]()
+		if union.Kind() != reflect.Interface {
+			p.err = fmt.Errorf("%w: the union %s is not an interface", ErrInvalidGrammar, union)
+			return
+		}
+
+		for _, member := range members {
+			p.unions[union] = append(p.unions[union], reflect.TypeOf(member))
+		}
+	})
+}
+
+// WithLexerOptions sets the options of the lexer used by ParseString
+func WithLexerOptions(options ...golex.LexerOptionFunc) ParserOptionFunc {
+	return ParserOptionFunc(func(p *parser) {
+		p.lexerOptions = append(p.lexerOptions, options...)
+	})
+}
+
+// NewParser compiles the grammar declared in the tags of T and the types it refers to
+func NewParser[T any](options ...ParserOptionFunc) (*Parser[T], error) {
+	p := &Parser[T]{parser: parser{structs: map[reflect.Type]*structGrammar{}, unions: map[reflect.Type][]reflect.Type{}}}
+	for _, option := range options {
+		option(&p.parser)
+	}
+
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	for union, members := range p.unions {
+		for _, member := range members {
+			t := member
+			if t != nil && t.Kind() == reflect.Pointer {
+				t = t.Elem()
+			}
+
+			if t == nil || !member.Implements(union) || t.Kind() != reflect.Struct {
+				return nil, fmt.Errorf("%w: %s is not a struct implementing %s", ErrInvalidGrammar, member, union)
+			}
+
+			if _, err := p.compile(t); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	grammar, err := p.compile(reflect.TypeFor[T]())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.leftRecursion(); err != nil {
+		return nil, err
+	}
+
+	p.grammar = grammar
+
+	return p, nil
+}
+
+// Parse parses all the tokens into a new value of T. A syntax error is returned as a *parse.Error.
+func (p *Parser[T]) Parse(tokens golex.Tokens) (*T, error) {
+	collection := golex.NewTokenCollection(tokens)
+
+	return p.parse(&collection, true)
+}
+
+// ParseString tokenizes the source using the lexer options of the parser and parses all of it
+func (p *Parser[T]) ParseString(filename string, source string) (*T, error) {
+	tokens := golex.Tokens{}
+	for token, err := range golex.NewLexer(p.lexerOptions...).IterateNamed(filename, source) {
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	return p.Parse(tokens)
+}
+
+// Match parses a value of T at the cursor of the tokens, moving the cursor past the match
+func (p *Parser[T]) Match(tokens *golex.TokenCollection) (*T, error) {
+	return p.parse(tokens, false)
+}
+
+func (p *Parser[T]) parse(tokens *golex.TokenCollection, complete bool) (*T, error) {
+	r := &run{parser: &p.parser, tokens: tokens, state: parse.NewState(tokens)}
+
+	value, ok := r.parseStruct(p.grammar)
+	if ok && complete {
+		_, ok = parse.EOF()(r.state)
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	if !ok {
+		if err := r.state.Err(); err != nil {
+			return nil, err
+		}
+
+		return nil, &parse.Error{Found: tokens.Peek(1)}
+	}
+
+	return value.Interface().(*T), nil
+}
+
+// ###################################################
+// #              Matching
+// ###################################################
+
+// run walks the struct grammars over the tokens, one is created per call to Parse or Match
+type run struct {
+	parser *parser
+	tokens *golex.TokenCollection
+	state  *parse.State
+	// err is a capture error, which stops the parse
+	err error
+}
+
+// parseStruct parses a new value of the struct, returning a pointer to it
+func (r *run) parseStruct(g *structGrammar) (reflect.Value, bool) {
+	start := r.tokens.Mark()
+	ptr := reflect.New(g.typ)
+
+	if !g.expr.match(r, ptr.Elem()) {
+		r.tokens.Reset(start)
+		return ptr, false
+	}
+
+	if g.pos >= 0 {
+		ptr.Elem().Field(g.pos).Set(reflect.ValueOf(r.tokens.TokenAtPosition(start).Position))
+	}
+
+	if g.endPos >= 0 {
+		end := r.tokens.TokenAtPosition(start).Position
+		if r.tokens.Cursor() > start {
+			end = r.tokens.TokenAtPosition(r.tokens.Cursor() - 1).End
+		}
+
+		ptr.Elem().Field(g.endPos).Set(reflect.ValueOf(end))
+	}
+
+	return ptr, true
+}
+
+// parseValue parses a value of the struct, pointer or union type
+func (r *run) parseValue(t reflect.Type) (reflect.Value, bool) {
+	switch t.Kind() {
+	case reflect.Struct:
+		ptr, ok := r.parseStruct(r.parser.structs[t])
+		return ptr.Elem(), ok
+
+	case reflect.Pointer:
+		return r.parseStruct(r.parser.structs[t.Elem()])
+
+	case reflect.Interface:
+		for _, member := range r.parser.unions[t] {
+			if value, ok := r.parseValue(member); ok {
+				return value.Convert(t), true
+			}
+
+			if r.err != nil {
+				break
+			}
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+// snapshot copies the struct value so it can be restored when backtracking
+func snapshot(v reflect.Value) reflect.Value {
+	saved := reflect.New(v.Type()).Elem()
+	saved.Set(v)
+
+	return saved
+}
+
+func (s sequence) match(r *run, v reflect.Value) bool {
+	for _, n := range s {
+		if !n.match(r, v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (a alternative) match(r *run, v reflect.Value) bool {
+	mark := r.tokens.Mark()
+	saved := snapshot(v)
+
+	for _, n := range a {
+		if n.match(r, v) {
+			return true
+		}
+
+		if r.err != nil {
+			return false
+		}
+
+		r.tokens.Reset(mark)
+		v.Set(saved)
+	}
+
+	return false
+}
+
+func (x *repetition) match(r *run, v reflect.Value) bool {
+	for count := 0; ; count++ {
+		mark := r.tokens.Mark()
+		saved := snapshot(v)
+
+		if !x.node.match(r, v) {
+			if r.err != nil {
+				return false
+			}
+
+			r.tokens.Reset(mark)
+			v.Set(saved)
+
+			return count >= x.min
+		}
+
+		// An expression that matches without consuming would match forever
+		if x.optional || r.tokens.Cursor() == mark {
+			return true
+		}
+	}
+}
+
+func (t *terminal) match(r *run, v reflect.Value) bool {
+	if !r.tokens.Peek(1).Is(t.pattern) {
+		r.state.Fail(t.pattern)
+		return false
+	}
+
+	r.tokens.Advance()
+	return true
+}
+
+func (c *capture) match(r *run, v reflect.Value) bool {
+	start := r.tokens.Mark()
+	if !c.node.match(r, v) {
+		return false
+	}
+
+	tokens := []golex.Token{}
+	for i := start; i < r.tokens.Cursor(); i++ {
+		tokens = append(tokens, r.tokens.TokenAtPosition(i))
+	}
+
+	if err := assign(v.Field(c.field), tokens); err != nil {
+		r.err = err
+		return false
+	}
+
+	return true
+}
+
+func (c *structCapture) match(r *run, v reflect.Value) bool {
+	field := v.Field(c.field)
+
+	if field.Kind() == reflect.Slice {
+		value, ok := r.parseValue(field.Type().Elem())
+		if ok {
+			field.Set(reflect.Append(field, value))
+		}
+
+		return ok
+	}
+
+	value, ok := r.parseValue(field.Type())
+	if ok {
+		field.Set(value)
+	}
+
+	return ok
+}
+
+// ###################################################
+// #              Captures
+// ###################################################
+
+// CaptureError is returned when captured tokens cannot be converted to the type of their field
+type CaptureError struct {
+	Token golex.Token
+	Type  reflect.Type
+	Err   error
+}
+
+// Position returns the position of the first captured token
+func (e *CaptureError) Position() golex.Position { return e.Token.Position }
+
+func (e *CaptureError) Error() string {
+	return fmt.Sprintf("%s: cannot capture '%s' into %s: %s", e.Token.Position.Location(), e.Token.Literal, e.Type, e.Err)
+}
+
+func (e *CaptureError) Unwrap() error { return e.Err }
+
+// assign converts the captured tokens to the type of the field
+func assign(field reflect.Value, tokens []golex.Token) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	if capturer, ok := field.Addr().Interface().(Capturer); ok {
+		if err := capturer.Capture(tokens); err != nil {
+			return &CaptureError{Token: tokens[0], Type: field.Type(), Err: err}
+		}
+
+		return nil
+	}
+
+	if field.Type() == tokenValueType {
+		field.Set(reflect.ValueOf(tokens[0]))
+		return nil
+	}
+
+	literal := ""
+	for _, token := range tokens {
+		literal += token.Literal
+	}
+
+	var err error
+	switch field.Kind() {
+	case reflect.Pointer:
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+
+		return assign(field.Elem(), tokens)
+
+	case reflect.Slice:
+		for _, token := range tokens {
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if err := assign(elem, []golex.Token{token}); err != nil {
+				return err
+			}
+
+			field.Set(reflect.Append(field, elem))
+		}
+
+	case reflect.String:
+		var sb strings.Builder
+		sb.WriteString(field.String())
+
+		for _, token := range tokens {
+			if value, ok := token.Value.(string); ok {
+				sb.WriteString(value)
+			} else {
+				sb.WriteString(token.Literal)
+			}
+		}
+
+		field.SetString(sb.String())
+
+	case reflect.Bool:
+		field.SetBool(true)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		if n, err = strconv.ParseInt(literal, 0, field.Type().Bits()); err == nil {
+			field.SetInt(n)
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n uint64
+		if n, err = strconv.ParseUint(literal, 0, field.Type().Bits()); err == nil {
+			field.SetUint(n)
+		}
+
+	case reflect.Float32, reflect.Float64:
+		var n float64
+		if n, err = strconv.ParseFloat(literal, field.Type().Bits()); err == nil {
+			field.SetFloat(n)
+		}
+	}
+
+	if err != nil {
+		return &CaptureError{Token: tokens[0], Type: field.Type(), Err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,249 @@
+package structs
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cornejong/golex"
+	"github.com/cornejong/golex/internal/golextest"
+	"github.com/cornejong/golex/parse"
+)
+
+type Config struct {
+	Entries []*Entry `parser:"@@*"`
+}
+
+type Entry struct {
+	Pos    golex.Position
+	EndPos golex.Position
+	Key    string `parser:"@Symbol ( @'.' @Symbol )* '='"`
+	Value  Value  `parser:"@@ ';'"`
+}
+
+type Value interface{ value() }
+
+type Number struct {
+	Value int `parser:"@'-'? @Integer"`
+}
+
+type String struct {
+	Value string `parser:"@( DoubleQuoteString | SingleQuoteString )"`
+}
+
+type Bool struct {
+	Value bool `parser:"@'true' | 'false'"`
+}
+
+type List struct {
+	Items []Value `parser:"'[' ( @@ ( ',' @@ )* )? ']'"`
+}
+
+type Call struct {
+	Name string      `parser:"@Symbol"`
+	Args []*Argument `parser:"'(' ( @@ ( ',' @@ )* )? ')'"`
+}
+
+type Argument struct {
+	Number *Number `parser:"@@"`
+	Flags  []Flag  `parser:"( @Symbol )*"`
+}
+
+// Flag converts the captured symbol itself
+type Flag string
+
+func (f *Flag) Capture(tokens []golex.Token) error {
+	*f = Flag(strconv.Quote(tokens[0].Literal))
+	return nil
+}
+
+// Sum is left recursive through the Value union when it is one of its members
+type Sum struct {
+	Left  Value   `parser:"@@"`
+	Right *Number `parser:"'+' @@"`
+}
+
+func (*Number) value() {}
+func (*String) value() {}
+func (*Bool) value()   {}
+func (*List) value()   {}
+func (*Sum) value()    {}
+
+func getConfigParser(t *testing.T) *Parser[Config] {
+	t.Helper()
+
+	p, err := NewParser[Config](WithUnion[Value](&Number{}, &String{}, &Bool{}, &List{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return p
+}
+
+func TestStructsParse(t *testing.T) {
+	fmt.Println("TestStructsParse...")
+
+	config, err := getConfigParser(t).ParseString("app.conf", `
+server.port = 8080;
+server.name = "api";
+debug = true;
+ports = [1, -2, [], 'x'];
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	differ := &golex.Differ{}
+	differ.Compare([]string{
+		`server.port = &structs.Number{Value:8080}`,
+		`server.name = &structs.String{Value:"api"}`,
+		`debug = &structs.Bool{Value:true}`,
+		`ports = [&structs.Number{Value:1} &structs.Number{Value:-2} &structs.List{Items:[]structs.Value(nil)} &structs.String{Value:"x"}]`,
+	}, func() []string {
+		entries := []string{}
+		for _, entry := range config.Entries {
+			if list, ok := entry.Value.(*List); ok {
+				items := []string{}
+				for _, item := range list.Items {
+					items = append(items, fmt.Sprintf("%#v", item))
+				}
+
+				entries = append(entries, fmt.Sprintf("%s = %s", entry.Key, items))
+				continue
+			}
+
+			entries = append(entries, fmt.Sprintf("%s = %#v", entry.Key, entry.Value))
+		}
+
+		return entries
+	}())
+
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+
+	entry := config.Entries[1]
+	if entry.Pos.Location() != "app.conf:3:1" || entry.EndPos.Location() != "app.conf:3:21" {
+		t.Errorf("Expected the entry to span 3:1 to 3:21 of app.conf but got %s to %s", entry.Pos.Location(), entry.EndPos.Location())
+	}
+
+	p, err := NewParser[Call]()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	call, err := p.ParseString("", "f(1 a b, 2)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	differ.Compare(`f [{1 ["a" "b"]} {2 []}]`, fmt.Sprintf("%s [{%d %s} {%d %s}]", call.Name, call.Args[0].Number.Value, call.Args[0].Flags, call.Args[1].Number.Value, call.Args[1].Flags))
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+}
+
+func TestStructsErrors(t *testing.T) {
+	fmt.Println("TestStructsErrors...")
+
+	p := getConfigParser(t)
+
+	_, err := p.ParseString("", "a = [1, 2 3];")
+
+	var parseErr *parse.Error
+	if !errors.As(err, &parseErr) || err.Error() != "1:11: expected ',' or ']' but found integer '3'" {
+		t.Errorf("Expected a parse error at the furthest position but got %v", err)
+	}
+
+	_, err = p.ParseString("", "a = 99999999999999999999;")
+
+	var captureErr *CaptureError
+	if !errors.As(err, &captureErr) || !errors.Is(err, strconv.ErrRange) || captureErr.Position().Col != 5 {
+		t.Errorf("Expected a capture error at 1:5 but got %v", err)
+	}
+
+	type Unknown struct {
+		Name string `parser:"@NoSuchType"`
+	}
+
+	if _, err := NewParser[Unknown](); !errors.Is(err, ErrInvalidGrammar) || !errors.Is(err, golex.ErrUnexpectedToken) {
+		t.Errorf("Expected an unknown token type to be invalid but got %v", err)
+	}
+
+	type Unbalanced struct {
+		Args []*Number `parser:"'(' @@*"`
+		Rest string    `parser:"@Symbol )"`
+	}
+
+	if _, err := NewParser[Unbalanced](); !errors.Is(err, ErrInvalidGrammar) {
+		t.Errorf("Expected an unbalanced group to be invalid but got %v", err)
+	}
+
+	if _, err := NewParser[Config](); !errors.Is(err, ErrInvalidGrammar) {
+		t.Errorf("Expected a union without members to be invalid but got %v", err)
+	}
+
+	// Match parses a value at the cursor and leaves the rest of the tokens
+	collection := golextest.Collection(t, "a = 1; }")
+	config, err := p.Match(collection)
+	if err != nil || len(config.Entries) != 1 || !collection.Peek(1).TypeIs(golex.TypeCloseCurly) {
+		t.Errorf("Expected a single entry followed by '}' but got %v", err)
+	}
+}
+
+func TestStructsLeftRecursion(t *testing.T) {
+	fmt.Println("TestStructsLeftRecursion...")
+
+	type Expr struct {
+		Left *Expr `parser:"(@@ '+')?"`
+		N    int   `parser:"@Integer"`
+	}
+
+	_, err := NewParser[Expr]()
+	if !errors.Is(err, ErrInvalidGrammar) || err.Error() != "invalid grammar: left recursion structs.Expr → structs.Expr" {
+		t.Errorf("Expected Expr to be left recursive but got %v", err)
+	}
+
+	// Fields which can match without consuming a token do not hide the recursion
+	type Padded struct {
+		Commas []string `parser:"@','*"`
+		Left   *Padded  `parser:"@@?"`
+		N      int      `parser:"@Integer"`
+	}
+
+	if _, err := NewParser[Padded](); !errors.Is(err, ErrInvalidGrammar) {
+		t.Errorf("Expected Padded to be left recursive but got %v", err)
+	}
+
+	_, err = NewParser[Sum](WithUnion[Value](&Sum{}, &Number{}))
+	if !errors.Is(err, ErrInvalidGrammar) || err.Error() != "invalid grammar: left recursion structs.Sum → structs.Sum" {
+		t.Errorf("Expected Sum to be left recursive through the union but got %v", err)
+	}
+
+	// Recursion after a token is fine
+	type Nested struct {
+		Inner *Nested `parser:"'(' @@? ')'"`
+	}
+
+	if _, err := NewParser[Nested](); err != nil {
+		t.Errorf("Expected Nested to be valid but got %v", err)
+	}
+
+	if _, err := NewParser[Sum](WithUnion[Value](&Number{})); err != nil {
+		t.Errorf("Expected Sum to be valid without being a member of Value but got %v", err)
+	}
+
+	// A pointer to a union cannot be parsed into
+	type Pointer struct {
+		V *Value `parser:"@@"`
+	}
+
+	_, err = NewParser[Pointer](WithUnion[Value](&Number{}))
+	if !errors.Is(err, ErrInvalidGrammar) || !strings.Contains(err.Error(), "@@ cannot parse into a pointer to the union structs.Value") {
+		t.Errorf("Expected a pointer to a union to be invalid but got %v", err)
+	}
+}
@@ -2,6 +2,8 @@ package golex
 
 import (
 	"fmt"
+	"io"
+	"os"
 )
 
 // ###################################################
@@ -12,6 +14,11 @@ type Token struct {
 	Literal  string
 	Value    any
 	Position Position
+	// End is the position directly after the last character of the token
+	End Position
+	// Pos is the compact position of the token within its FileSet,
+	// it is NoPos when the source was not registered in a FileSet
+	Pos Pos
 }
 
 func (t *Token) AppendChar(char ...rune) {
@@ -19,7 +26,12 @@ func (t *Token) AppendChar(char ...rune) {
 }
 
 func (t Token) Dump() {
-	fmt.Printf("%s -> %-22s%-22s(%v)\n", t.Position.String(), t.Type.String(), t.Literal, t.Value)
+	t.Fdump(os.Stdout)
+}
+
+// Fdump writes the token in the Dump table format to w
+func (t Token) Fdump(w io.Writer) {
+	fmt.Fprintf(w, "%s -> %-22s%-22s(%v)\n", t.Position.String(), t.Type.String(), t.Literal, t.Value)
 }
 
 func (t Token) Is(token Token) bool {
@@ -82,6 +94,15 @@ type Position struct {
 	Row    int
 	Col    int
 	Cursor int
+	// Filename is the name of the source, if it was provided
+	Filename string
+	// Offset is the UTF-8 byte offset, only set when tracking byte offsets
+	Offset int
+	// UTF16Col is the column in UTF-16 code units, only set when tracking UTF-16 columns
+	UTF16Col int
+	// VisualCol is the displayed column taking tabs and wide characters into account,
+	// only set when tracking visual columns
+	VisualCol int
 }
 
 func (p Position) String() string {
@@ -0,0 +1,558 @@
+package golex
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ###################################################
+// #                    JSON
+// ###################################################
+
+const (
+	valueTypeString = "string"
+	valueTypeInt    = "int"
+	valueTypeFloat  = "float"
+	valueTypeBool   = "bool"
+	valueTypeAny    = "any"
+)
+
+type jsonToken struct {
+	Type      string          `json:"type"`
+	Literal   string          `json:"literal"`
+	Value     json.RawMessage `json:"value,omitempty"`
+	ValueType string          `json:"valueType,omitempty"`
+	Position  Position        `json:"position"`
+	End       Position        `json:"end"`
+	Pos       Pos             `json:"pos,omitempty"`
+}
+
+type jsonPosition struct {
+	Row       int    `json:"row"`
+	Col       int    `json:"col"`
+	Cursor    int    `json:"cursor"`
+	Filename  string `json:"filename,omitempty"`
+	Offset    int    `json:"offset,omitempty"`
+	UTF16Col  int    `json:"utf16Col,omitempty"`
+	VisualCol int    `json:"visualCol,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+// The token type is encoded by name and can be decoded as long as it is registered.
+func (t Token) MarshalJSON() ([]byte, error) {
+	if t.Type == nil {
+		return nil, fmt.Errorf("cannot marshal token without a type")
+	}
+
+	jt := jsonToken{
+		Type:     t.Type.String(),
+		Literal:  t.Literal,
+		Position: t.Position,
+		End:      t.End,
+		Pos:      t.Pos,
+	}
+
+	if t.Value != nil {
+		var err error
+		if jt.Value, err = json.Marshal(t.Value); err != nil {
+			return nil, err
+		}
+
+		jt.ValueType = valueTypeOf(t.Value)
+	}
+
+	return json.Marshal(jt)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// The token type is resolved using the token type registry.
+func (t *Token) UnmarshalJSON(data []byte) error {
+	jt := jsonToken{}
+	if err := json.Unmarshal(data, &jt); err != nil {
+		return err
+	}
+
+	tokenType, err := lookupTokenType(jt.Type)
+	if err != nil {
+		return err
+	}
+
+	var value any
+	if len(jt.Value) > 0 {
+		if value, err = decodeJSONValue(jt.ValueType, jt.Value); err != nil {
+			return err
+		}
+	}
+
+	*t = Token{
+		Type:     tokenType,
+		Literal:  jt.Literal,
+		Value:    value,
+		Position: jt.Position,
+		End:      jt.End,
+		Pos:      jt.Pos,
+	}
+
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (p Position) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonPosition(p))
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (p *Position) UnmarshalJSON(data []byte) error {
+	jp := jsonPosition{}
+	if err := json.Unmarshal(data, &jp); err != nil {
+		return err
+	}
+
+	*p = Position(jp)
+	return nil
+}
+
+func valueTypeOf(value any) string {
+	switch value.(type) {
+	case string:
+		return valueTypeString
+	case int:
+		return valueTypeInt
+	case float64:
+		return valueTypeFloat
+	case bool:
+		return valueTypeBool
+	default:
+		return valueTypeAny
+	}
+}
+
+func decodeJSONValue(valueType string, raw json.RawMessage) (any, error) {
+	var err error
+
+	switch valueType {
+	case valueTypeString:
+		var v string
+		err = json.Unmarshal(raw, &v)
+		return v, err
+	case valueTypeInt:
+		var v int
+		err = json.Unmarshal(raw, &v)
+		return v, err
+	case valueTypeFloat:
+		var v float64
+		err = json.Unmarshal(raw, &v)
+		return v, err
+	case valueTypeBool:
+		var v bool
+		err = json.Unmarshal(raw, &v)
+		return v, err
+	default:
+		var v any
+		err = json.Unmarshal(raw, &v)
+		return v, err
+	}
+}
+
+// ###################################################
+// #                    Binary
+// ###################################################
+
+// ErrMalformedBinary is returned when decoding malformed binary token data
+var ErrMalformedBinary = errors.New("malformed binary token data")
+
+// The encodings of a single token and of a token collection start with their own
+// magic, followed by the version of the format they share
+const (
+	binaryTokenMagic    = "GLT"
+	binaryTokensMagic   = "GLX"
+	binaryTokensVersion = 1
+)
+
+const (
+	binaryValueNil byte = iota
+	binaryValueString
+	binaryValueInt
+	binaryValueFloat
+	binaryValueBool
+	binaryValueJSON
+)
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface
+func (t Token) MarshalBinary() ([]byte, error) {
+	if t.Type == nil {
+		return nil, fmt.Errorf("cannot marshal token without a type")
+	}
+
+	w := newBinaryWriter(binaryTokenMagic)
+	w.String(t.Type.String())
+	w.Filenames(t)
+	if err := w.Token(t); err != nil {
+		return nil, err
+	}
+
+	return w.buf, nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface
+func (t *Token) UnmarshalBinary(data []byte) error {
+	r, err := newBinaryReader(data, binaryTokenMagic)
+	if err != nil {
+		return err
+	}
+
+	tokenType, err := lookupTokenType(r.String())
+	if err != nil {
+		return err
+	}
+
+	r.Filenames()
+	token := r.Token(tokenType)
+	if r.err != nil {
+		return r.err
+	}
+
+	*t = token
+	return nil
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+// The token types and filenames are stored once in tables which keep
+// the encoding of long token streams compact.
+func (t Tokens) MarshalBinary() ([]byte, error) {
+	w := newBinaryWriter(binaryTokensMagic)
+
+	typeIndexes := map[string]int{}
+	typeNames := []string{}
+	for _, token := range t {
+		if token.Type == nil {
+			return nil, fmt.Errorf("cannot marshal token without a type")
+		}
+
+		name := token.Type.String()
+		if _, ok := typeIndexes[name]; !ok {
+			typeIndexes[name] = len(typeNames)
+			typeNames = append(typeNames, name)
+		}
+	}
+
+	w.Uvarint(uint64(len(typeNames)))
+	for _, name := range typeNames {
+		w.String(name)
+	}
+
+	w.Filenames(t...)
+
+	w.Uvarint(uint64(len(t)))
+	for _, token := range t {
+		w.Uvarint(uint64(typeIndexes[token.Type.String()]))
+		if err := w.Token(token); err != nil {
+			return nil, err
+		}
+	}
+
+	return w.buf, nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface
+func (t *Tokens) UnmarshalBinary(data []byte) error {
+	r, err := newBinaryReader(data, binaryTokensMagic)
+	if err != nil {
+		return err
+	}
+
+	types := make([]TokenType, r.Length())
+	for i := range types {
+		var err error
+		if types[i], err = lookupTokenType(r.String()); r.err == nil && err != nil {
+			return err
+		}
+	}
+
+	r.Filenames()
+
+	tokens := make(Tokens, 0, r.Length())
+	for i := 0; i < cap(tokens) && r.err == nil; i++ {
+		index := r.Uvarint()
+		if r.err == nil && index >= uint64(len(types)) {
+			return fmt.Errorf("%w: type index %d out of range", ErrMalformedBinary, index)
+		}
+
+		if r.err == nil {
+			tokens = append(tokens, r.Token(types[index]))
+		}
+	}
+
+	if r.err != nil {
+		return r.err
+	}
+
+	*t = tokens
+	return nil
+}
+
+type binaryWriter struct {
+	buf []byte
+	// filenames holds the index of every filename in the filename table
+	filenames map[string]uint64
+}
+
+// newBinaryWriter creates a writer which starts with the magic and the version
+func newBinaryWriter(magic string) *binaryWriter {
+	w := &binaryWriter{buf: []byte(magic)}
+	w.Uvarint(binaryTokensVersion)
+
+	return w
+}
+
+func (w *binaryWriter) Uvarint(v uint64) {
+	w.buf = binary.AppendUvarint(w.buf, v)
+}
+
+func (w *binaryWriter) Varint(v int64) {
+	w.buf = binary.AppendVarint(w.buf, v)
+}
+
+func (w *binaryWriter) String(s string) {
+	w.Uvarint(uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+// Filenames writes the table of the filenames in the positions of the tokens,
+// the positions refer to their filename by its index in the table
+func (w *binaryWriter) Filenames(tokens ...Token) {
+	w.filenames = map[string]uint64{}
+	names := []string{}
+
+	for _, token := range tokens {
+		for _, name := range []string{token.Position.Filename, token.End.Filename} {
+			if _, ok := w.filenames[name]; !ok {
+				w.filenames[name] = uint64(len(names))
+				names = append(names, name)
+			}
+		}
+	}
+
+	w.Uvarint(uint64(len(names)))
+	for _, name := range names {
+		w.String(name)
+	}
+}
+
+func (w *binaryWriter) Position(p Position) {
+	w.Varint(int64(p.Row))
+	w.Varint(int64(p.Col))
+	w.Varint(int64(p.Cursor))
+	w.Uvarint(w.filenames[p.Filename])
+	w.Varint(int64(p.Offset))
+	w.Varint(int64(p.UTF16Col))
+	w.Varint(int64(p.VisualCol))
+}
+
+// Token writes everything but the token type
+func (w *binaryWriter) Token(t Token) error {
+	w.String(t.Literal)
+
+	switch v := t.Value.(type) {
+	case nil:
+		w.buf = append(w.buf, binaryValueNil)
+	case string:
+		w.buf = append(w.buf, binaryValueString)
+		w.String(v)
+	case int:
+		w.buf = append(w.buf, binaryValueInt)
+		w.Varint(int64(v))
+	case float64:
+		w.buf = append(w.buf, binaryValueFloat)
+		w.buf = binary.LittleEndian.AppendUint64(w.buf, math.Float64bits(v))
+	case bool:
+		w.buf = append(w.buf, binaryValueBool)
+		if v {
+			w.buf = append(w.buf, 1)
+		} else {
+			w.buf = append(w.buf, 0)
+		}
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+
+		w.buf = append(w.buf, binaryValueJSON)
+		w.String(string(encoded))
+	}
+
+	w.Position(t.Position)
+	w.Position(t.End)
+	w.Varint(int64(t.Pos))
+	return nil
+}
+
+// binaryReader reads the binary token encoding. The first error
+// encountered is retained and all subsequent reads return zero values.
+type binaryReader struct {
+	buf       []byte
+	err       error
+	filenames []string
+}
+
+// newBinaryReader checks the magic and the version at the start of the data
+// and returns a reader of the rest
+func newBinaryReader(data []byte, magic string) (*binaryReader, error) {
+	if len(data) < len(magic) || string(data[:len(magic)]) != magic {
+		return nil, fmt.Errorf("%w: missing header", ErrMalformedBinary)
+	}
+
+	r := &binaryReader{buf: data[len(magic):]}
+	version := r.Uvarint()
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	if version != binaryTokensVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrMalformedBinary, version)
+	}
+
+	return r, nil
+}
+
+func (r *binaryReader) fail(reason string) {
+	if r.err == nil {
+		r.err = fmt.Errorf("%w: %s", ErrMalformedBinary, reason)
+	}
+}
+
+func (r *binaryReader) Uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.fail("invalid uvarint")
+		return 0
+	}
+
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *binaryReader) Varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, n := binary.Varint(r.buf)
+	if n <= 0 {
+		r.fail("invalid varint")
+		return 0
+	}
+
+	r.buf = r.buf[n:]
+	return v
+}
+
+// Length reads a length prefix and makes sure it can not exceed the remaining data
+func (r *binaryReader) Length() int {
+	length := r.Uvarint()
+	if length > uint64(len(r.buf)) {
+		r.fail("length exceeds data")
+		return 0
+	}
+
+	return int(length)
+}
+
+func (r *binaryReader) Bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+
+	if n > len(r.buf) {
+		r.fail("unexpected end of data")
+		return nil
+	}
+
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *binaryReader) String() string {
+	return string(r.Bytes(r.Length()))
+}
+
+// Filenames reads the filename table the positions refer to
+func (r *binaryReader) Filenames() {
+	r.filenames = make([]string, r.Length())
+	for i := range r.filenames {
+		r.filenames[i] = r.String()
+	}
+}
+
+// Filename reads the index of a filename in the filename table
+func (r *binaryReader) Filename() string {
+	index := r.Uvarint()
+	if r.err == nil && index >= uint64(len(r.filenames)) {
+		r.fail(fmt.Sprintf("filename index %d out of range", index))
+	}
+
+	if r.err != nil {
+		return ""
+	}
+
+	return r.filenames[index]
+}
+
+func (r *binaryReader) Position() Position {
+	return Position{
+		Row:       int(r.Varint()),
+		Col:       int(r.Varint()),
+		Cursor:    int(r.Varint()),
+		Filename:  r.Filename(),
+		Offset:    int(r.Varint()),
+		UTF16Col:  int(r.Varint()),
+		VisualCol: int(r.Varint()),
+	}
+}
+
+func (r *binaryReader) Token(tokenType TokenType) Token {
+	token := Token{Type: tokenType, Literal: r.String()}
+
+	kind := r.Bytes(1)
+	if r.err != nil {
+		return token
+	}
+
+	switch kind[0] {
+	case binaryValueNil:
+	case binaryValueString:
+		token.Value = r.String()
+	case binaryValueInt:
+		token.Value = int(r.Varint())
+	case binaryValueFloat:
+		if b := r.Bytes(8); b != nil {
+			token.Value = math.Float64frombits(binary.LittleEndian.Uint64(b))
+		}
+	case binaryValueBool:
+		if b := r.Bytes(1); b != nil {
+			token.Value = b[0] == 1
+		}
+	case binaryValueJSON:
+		if raw := r.String(); r.err == nil {
+			if err := json.Unmarshal([]byte(raw), &token.Value); err != nil {
+				r.fail(err.Error())
+			}
+		}
+	default:
+		r.fail(fmt.Sprintf("unknown value kind %d", kind[0]))
+	}
+
+	token.Position = r.Position()
+	token.End = r.Position()
+	token.Pos = Pos(r.Varint())
+	return token
+}
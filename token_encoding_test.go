@@ -0,0 +1,216 @@
+package golex
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestTokenJSONRoundTrip(t *testing.T) {
+	fmt.Println("TestTokenJSONRoundTrip...")
+
+	data, err := json.Marshal(expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokens := []Token{}
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		t.Fatal(err)
+	}
+
+	differ := &Differ{}
+	differ.Compare(expected, tokens)
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		fmt.Printf("\n%d differences between expected and result\n", len(differ.Diffs))
+		t.FailNow()
+	}
+}
+
+func TestTokenJSONCustomType(t *testing.T) {
+	fmt.Println("TestTokenJSONCustomType...")
+
+	lexer := NewLexer(WithLiteralTokens(LiteralToken{Type: Type("FatArrow"), Literal: "=>"}))
+	tokens, err := lexer.TokenizeToSlice("a => 1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := []Token{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	differ := &Differ{}
+	differ.Compare(tokens, decoded)
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+
+	err = json.Unmarshal([]byte(`{"type":"NotARegisteredType","literal":"x"}`), &Token{})
+	if !errors.Is(err, ErrUnknownTokenType) {
+		t.Errorf("Expected ErrUnknownTokenType but got %v", err)
+	}
+}
+
+var registrationRuns atomic.Int32
+
+func TestTokenTypeRegistration(t *testing.T) {
+	fmt.Println("TestTokenTypeRegistration...")
+
+	// The registry outlives the test, so every run registers a new name
+	name := fmt.Sprintf("TildeArrow%d", registrationRuns.Add(1))
+
+	if Type("Semicolon") != TypeSemicolon || Type(name) != CustomType(name) {
+		t.Errorf("Expected Type to return the registered type or a CustomType")
+	}
+
+	if _, ok := LookupTokenType(name); ok {
+		t.Errorf("Expected Type not to register %s", name)
+	}
+
+	option := WithLiteralTokens(LiteralToken{Type: Type(name), Literal: "~>"})
+	if _, ok := LookupTokenType(name); ok {
+		t.Errorf("Expected WithLiteralTokens to register %s when applied", name)
+	}
+
+	if lexer := NewLexer(option); lexer.Err() != nil {
+		t.Errorf("Unexpected error %v", lexer.Err())
+	}
+
+	if tokenType, ok := LookupTokenType(name); !ok || tokenType != CustomType(name) {
+		t.Errorf("Expected %s to be registered but got %v", name, tokenType)
+	}
+
+	// A name taken by another type is reported by the lexer
+	lexer := NewLexer(WithStringEnclosure(StringEnclosure{Type: CustomType("Semicolon"), Enclosure: "%"}))
+	if lexer.Err() == nil {
+		t.Fatalf("Expected an error for the Semicolon string type")
+	}
+
+	if _, err := lexer.TokenizeToSlice("a"); err != lexer.Err() {
+		t.Errorf("Expected the option error but got %v", err)
+	}
+}
+
+func TestTokensBinaryRoundTrip(t *testing.T) {
+	fmt.Println("TestTokensBinaryRoundTrip...")
+
+	data, err := Tokens(expected).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokens := Tokens{}
+	if err := tokens.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	differ := &Differ{}
+	differ.Compare(Tokens(expected), tokens)
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		fmt.Printf("\n%d differences between expected and result\n", len(differ.Diffs))
+		t.FailNow()
+	}
+
+	for i := 0; i < len(data); i++ {
+		if err := (&Tokens{}).UnmarshalBinary(data[:i]); err == nil {
+			t.Errorf("Expected an error decoding truncated data of length %d", i)
+		}
+	}
+}
+
+func TestTokensBinaryFilenames(t *testing.T) {
+	fmt.Println("TestTokensBinaryFilenames...")
+
+	tokens := Tokens{}
+	for token, err := range NewLexer().IterateNamed("filenames.dsl", "a = b + c") {
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	data, err := tokens.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count := bytes.Count(data, []byte("filenames.dsl")); count != 1 {
+		t.Errorf("Expected the filename to be encoded once but found it %d times", count)
+	}
+
+	decoded := Tokens{}
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	differ := &Differ{}
+	differ.Compare(tokens, decoded)
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+}
+
+func TestTokenBinaryRoundTrip(t *testing.T) {
+	fmt.Println("TestTokenBinaryRoundTrip...")
+
+	for _, token := range expected {
+		data, err := token.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		decoded := Token{}
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+
+		differ := &Differ{}
+		differ.Compare(token, decoded)
+		if differ.HasDifference() {
+			fmt.Println(differ)
+			t.FailNow()
+		}
+
+		if !bytes.HasPrefix(data, []byte{'G', 'L', 'T', 1}) {
+			t.Fatalf("Expected the token to start with the magic and version 1 but got %q", data[:4])
+		}
+
+		for i := 0; i < len(data); i++ {
+			if err := (&Token{}).UnmarshalBinary(data[:i]); err == nil {
+				t.Errorf("Expected an error decoding truncated data of length %d", i)
+			}
+		}
+	}
+
+	// The token and collection encodings are told apart by their magic
+	collection, _ := Tokens(expected).MarshalBinary()
+	if err := (&Token{}).UnmarshalBinary(collection); !errors.Is(err, ErrMalformedBinary) {
+		t.Errorf("Expected a collection not to decode as a token but got %v", err)
+	}
+
+	data, _ := expected[0].MarshalBinary()
+	if err := (&Tokens{}).UnmarshalBinary(data); !errors.Is(err, ErrMalformedBinary) {
+		t.Errorf("Expected a token not to decode as a collection but got %v", err)
+	}
+
+	data[3] = 2
+	if err := (&Token{}).UnmarshalBinary(data); !errors.Is(err, ErrMalformedBinary) || !strings.Contains(err.Error(), "unsupported version 2") {
+		t.Errorf("Expected an unsupported version error but got %v", err)
+	}
+}
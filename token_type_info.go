@@ -0,0 +1,139 @@
+package golex
+
+// TokenCategory classifies token types
+type TokenCategory int
+
+const (
+	CategoryNone TokenCategory = iota
+	CategorySpecial
+	CategoryLiteral
+	CategoryKeyword
+	CategoryIdentifier
+	CategoryComment
+	CategoryOperator
+	CategoryPunctuation
+	CategoryBracket
+	CategoryWhitespace
+)
+
+func (c TokenCategory) String() string {
+	switch c {
+	case CategorySpecial:
+		return "Special"
+	case CategoryLiteral:
+		return "Literal"
+	case CategoryKeyword:
+		return "Keyword"
+	case CategoryIdentifier:
+		return "Identifier"
+	case CategoryComment:
+		return "Comment"
+	case CategoryOperator:
+		return "Operator"
+	case CategoryPunctuation:
+		return "Punctuation"
+	case CategoryBracket:
+		return "Bracket"
+	case CategoryWhitespace:
+		return "Whitespace"
+	default:
+		return "None"
+	}
+}
+
+// TokenTypeInfo holds the metadata of a token type
+type TokenTypeInfo struct {
+	Category TokenCategory
+	// DisplayName is the human-readable name used in messages
+	DisplayName string
+	// ClosedBy is the closing partner of an opening bracket
+	ClosedBy TokenType
+	// OpenedBy is the opening partner of a closing bracket
+	OpenedBy TokenType
+}
+
+// RegisterTokenTypeInfo registers the token type and its metadata.
+// The metadata replaces any previously registered metadata of the type.
+func RegisterTokenTypeInfo(t TokenType, info TokenTypeInfo) error {
+	if err := RegisterTokenType(t); err != nil {
+		return err
+	}
+
+	tokenTypeRegistry.Lock()
+	defer tokenTypeRegistry.Unlock()
+
+	tokenTypeRegistry.infos[t] = info
+	return nil
+}
+
+// TypeInfo returns the metadata of the token type.
+// The display name defaults to the name of the type.
+func TypeInfo(t TokenType) TokenTypeInfo {
+	if t == nil {
+		return TokenTypeInfo{}
+	}
+
+	tokenTypeRegistry.RLock()
+	info := tokenTypeRegistry.infos[t]
+	tokenTypeRegistry.RUnlock()
+
+	if info.DisplayName == "" {
+		info.DisplayName = t.String()
+	}
+
+	return info
+}
+
+// CategoryOf returns the category of the token type
+func CategoryOf(t TokenType) TokenCategory {
+	return TypeInfo(t).Category
+}
+
+// DisplayName returns the human-readable name of the token type
+func DisplayName(t TokenType) string {
+	return TypeInfo(t).DisplayName
+}
+
+// ClosingPair returns the closing partner of an opening bracket type or nil
+func ClosingPair(t TokenType) TokenType {
+	return TypeInfo(t).ClosedBy
+}
+
+// OpeningPair returns the opening partner of a closing bracket type or nil
+func OpeningPair(t TokenType) TokenType {
+	return TypeInfo(t).OpenedBy
+}
+
+func IsOperator(t TokenType) bool    { return CategoryOf(t) == CategoryOperator }
+func IsLiteral(t TokenType) bool     { return CategoryOf(t) == CategoryLiteral }
+func IsPunctuation(t TokenType) bool { return CategoryOf(t) == CategoryPunctuation }
+func IsBracket(t TokenType) bool     { return CategoryOf(t) == CategoryBracket }
+func IsKeyword(t TokenType) bool     { return CategoryOf(t) == CategoryKeyword }
+func IsComment(t TokenType) bool     { return CategoryOf(t) == CategoryComment }
+func IsWhitespace(t TokenType) bool  { return CategoryOf(t) == CategoryWhitespace }
+
+// IsTrivia checks if the token type carries no syntactic meaning, being whitespace or comments
+func IsTrivia(t TokenType) bool {
+	category := CategoryOf(t)
+	return category == CategoryWhitespace || category == CategoryComment
+}
+
+// IsOpeningBracket checks if the token type is a bracket with a closing partner
+func IsOpeningBracket(t TokenType) bool {
+	return ClosingPair(t) != nil
+}
+
+// IsClosingBracket checks if the token type is a bracket with an opening partner
+func IsClosingBracket(t TokenType) bool {
+	return OpeningPair(t) != nil
+}
+
+// Info returns the metadata of the token's type
+func (t Token) Info() TokenTypeInfo {
+	return TypeInfo(t.Type)
+}
+
+// Category returns the category of the token's type
+func (t Token) Category() TokenCategory {
+	return CategoryOf(t.Type)
+}
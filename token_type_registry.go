@@ -0,0 +1,109 @@
+package golex
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// ErrUnknownTokenType is returned when a token type name is not registered
+var ErrUnknownTokenType = errors.New("unknown token type")
+
+// CustomType is a user defined token type as returned by Type
+type CustomType string
+
+func (ct CustomType) String() string {
+	return string(ct)
+}
+
+// tokenTypeRegistry maps token type names back to their TokenType values
+// and holds the metadata of the token types
+var tokenTypeRegistry = struct {
+	sync.RWMutex
+	types map[string]TokenType
+	infos map[TokenType]TokenTypeInfo
+}{types: map[string]TokenType{}, infos: map[TokenType]TokenTypeInfo{}}
+
+func init() {
+	for _, t := range buildInTypes {
+		tokenTypeRegistry.types[t.String()] = t
+	}
+
+	for t, info := range buildInTypeInfo {
+		tokenTypeRegistry.infos[t] = info
+	}
+}
+
+// Type returns the registered token type with the provided name, or a CustomType
+// with that name when none is registered. It does not register the type,
+// which is done by RegisterTokenType or the lexer options declaring the type.
+func Type(name string) TokenType {
+	if t, ok := LookupTokenType(name); ok {
+		return t
+	}
+
+	return CustomType(name)
+}
+
+// RegisterTokenType registers the token types so they can be looked up by name.
+// Registering a type twice is a no-op, registering a different type under
+// an already registered name returns an error.
+func RegisterTokenType(types ...TokenType) error {
+	tokenTypeRegistry.Lock()
+	defer tokenTypeRegistry.Unlock()
+
+	for _, t := range types {
+		name := t.String()
+		if registered, ok := tokenTypeRegistry.types[name]; ok && registered != t {
+			return fmt.Errorf("token type %q is already registered as %T", name, registered)
+		}
+
+		tokenTypeRegistry.types[name] = t
+	}
+
+	return nil
+}
+
+// LookupTokenType returns the registered token type with the provided name
+func LookupTokenType(name string) (TokenType, bool) {
+	tokenTypeRegistry.RLock()
+	defer tokenTypeRegistry.RUnlock()
+
+	t, ok := tokenTypeRegistry.types[name]
+	return t, ok
+}
+
+// RegisteredTokenTypes returns all the registered token types sorted by name
+func RegisteredTokenTypes() []TokenType {
+	tokenTypeRegistry.RLock()
+	defer tokenTypeRegistry.RUnlock()
+
+	types := []TokenType{}
+	for _, t := range tokenTypeRegistry.types {
+		types = append(types, t)
+	}
+
+	slices.SortFunc(types, func(a, b TokenType) int {
+		if a.String() < b.String() {
+			return -1
+		}
+
+		if a.String() > b.String() {
+			return 1
+		}
+
+		return 0
+	})
+
+	return types
+}
+
+func lookupTokenType(name string) (TokenType, error) {
+	t, ok := LookupTokenType(name)
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownTokenType, name)
+	}
+
+	return t, nil
+}
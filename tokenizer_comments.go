@@ -6,8 +6,6 @@ var (
 	SlashSingleLineCommentSyntax   = CommentSyntax{Opener: "//"}
 	SlashMultilineCommentSyntax    = CommentSyntax{Opener: "/*", Closer: "*/"}
 	HashtagSingleLineCommentSyntax = CommentSyntax{Opener: "#"}
-
-	cachedCommentSyntax *CommentSyntax
 )
 
 type CommentSyntax struct {
@@ -24,7 +22,7 @@ func (c CommentTokenizer) CanTokenize(l *Lexer) bool {
 
 	for _, syntax := range l.CommentSyntaxes {
 		if l.NextCharsAre([]rune(syntax.Opener)) {
-			cachedCommentSyntax = &syntax
+			l.cachedCommentSyntax = &syntax
 			return true
 		}
 	}
@@ -33,39 +31,42 @@ func (c CommentTokenizer) CanTokenize(l *Lexer) bool {
 }
 
 func (c CommentTokenizer) Tokenize(l *Lexer) (Token, error) {
-	if cachedCommentSyntax == nil {
+	if l.cachedCommentSyntax == nil {
 		if !c.CanTokenize(l) {
 			return Token{Type: TypeInvalid, Position: l.GetPosition()},
-				NewError(fmt.Sprintf("Invalid token '%c' found", l.CharAtCursor()), l.GetPosition(), l.state.Content)
+				l.ErrorAt(ErrInvalidCharacter, fmt.Sprintf("Invalid token '%c' found", l.CharAtCursor()), l.GetPosition())
 		} else {
 			return c.Tokenize(l)
 		}
 	}
 
 	var reachedEndOfComment func(*Lexer) bool
-	if cachedCommentSyntax.Closer == "" {
+	if l.cachedCommentSyntax.Closer == "" {
 		reachedEndOfComment = func(l *Lexer) bool {
-			return l.CharAtCursor() == '\n'
+			return l.NewlineLengthAtCursor() > 0
 		}
 	} else {
 		reachedEndOfComment = func(l *Lexer) bool {
-			return l.NextCharsAre([]rune(cachedCommentSyntax.Closer))
+			return l.NextCharsAre([]rune(l.cachedCommentSyntax.Closer))
 		}
 	}
 
 	token := Token{Type: TypeComment, Position: l.GetPosition()}
-	for l.GetCursor() <= l.state.ContentLength && !reachedEndOfComment(l) {
+	for !l.CursorIsOutOfBounds() && !reachedEndOfComment(l) {
 		token.AppendChar(l.CharAtCursor())
 		l.IncrementCursor(1)
 	}
 
-	l.IncrementCursor(len(cachedCommentSyntax.Closer))
+	if l.CursorIsOutOfBounds() && l.cachedCommentSyntax.Closer != "" {
+		l.cachedCommentSyntax = nil
+		return token, l.ErrorAt(ErrUnterminatedComment, "Unterminated comment", token.Position)
+	}
 
-	cachedCommentSyntax = nil
+	// Leave the cursor on the last character of the closer, or the
+	// last character of the comment for single line comments
+	l.IncrementCursor(len([]rune(l.cachedCommentSyntax.Closer)) - 1)
 
-	if l.IgnoreComments {
-		return l.NextToken()
-	}
+	l.cachedCommentSyntax = nil
 
 	return token, nil
 }
@@ -4,10 +4,6 @@ import (
 	"slices"
 )
 
-var (
-	cachedLiteralToken *Token
-)
-
 type LiteralTokenizerCacheKey string
 
 type LiteralTokenizer struct{}
@@ -16,7 +12,7 @@ func (t LiteralTokenizer) CanTokenize(l *Lexer) bool {
 	pos := l.GetPosition()
 	for _, literal := range l.LiteralTokens {
 		if l.NextCharsAre([]rune(literal.Literal)) {
-			cachedLiteralToken = &Token{
+			l.cachedLiteralToken = &Token{
 				Type:     literal.Type,
 				Literal:  literal.Literal,
 				Position: pos,
@@ -29,9 +25,12 @@ func (t LiteralTokenizer) CanTokenize(l *Lexer) bool {
 }
 
 func (t LiteralTokenizer) Tokenize(l *Lexer) (Token, error) {
-	if cachedLiteralToken != nil {
-		token := *cachedLiteralToken
-		cachedLiteralToken = nil
+	if l.cachedLiteralToken != nil {
+		token := *l.cachedLiteralToken
+		l.cachedLiteralToken = nil
+
+		// Leave the cursor on the last character of the literal
+		l.IncrementCursor(len([]rune(token.Literal)) - 1)
 		return token, nil
 	}
 
@@ -68,4 +67,6 @@ func SortLiteralTokens(tokens []LiteralToken) []LiteralToken {
 type LiteralToken struct {
 	Type    TokenType
 	Literal string
+	// Info optionally declares the metadata of the token type
+	Info TokenTypeInfo
 }
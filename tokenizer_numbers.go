@@ -32,12 +32,12 @@ func (n NumberTokenizer) Tokenize(l *Lexer) (Token, error) {
 
 	if token.Type == TypeFloat {
 		if strings.HasSuffix(token.Literal, ".") {
-			return token, NewError(fmt.Sprintf("Malformed float '%s'. Missing Decimal places.", token.Literal), token.Position, l.state.Content)
+			return token, l.ErrorAt(ErrMalformedNumber, fmt.Sprintf("Malformed float '%s'. Missing Decimal places.", token.Literal), token.Position)
 		}
 
 		decimalSeparatorCount := strings.Count(token.Literal, ".")
 		if decimalSeparatorCount > 1 {
-			return token, NewError(fmt.Sprintf("Malformed float '%s'. To many decimal separators. Expect 1 but got %d", token.Literal, decimalSeparatorCount), token.Position, l.state.Content)
+			return token, l.ErrorAt(ErrMalformedNumber, fmt.Sprintf("Malformed float '%s'. To many decimal separators. Expect 1 but got %d", token.Literal, decimalSeparatorCount), token.Position)
 		}
 
 		// TODO: Make a lexer option to enable number parsing errors
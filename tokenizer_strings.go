@@ -20,8 +20,6 @@ var (
 		Type:      TypeTripleBacktickString,
 		Enclosure: "```",
 	}
-
-	cachedStringEnclosure *StringEnclosure
 )
 
 type StringTokenizer struct{}
@@ -29,7 +27,7 @@ type StringTokenizer struct{}
 func (s StringTokenizer) CanTokenize(l *Lexer) bool {
 	for _, enclosure := range l.StringEnclosures {
 		if l.NextCharsAre([]rune(enclosure.Enclosure)) {
-			cachedStringEnclosure = &enclosure
+			l.cachedStringEnclosure = &enclosure
 			return true
 		}
 	}
@@ -38,17 +36,17 @@ func (s StringTokenizer) CanTokenize(l *Lexer) bool {
 }
 
 func (s StringTokenizer) Tokenize(l *Lexer) (Token, error) {
-	if cachedStringEnclosure == nil {
+	if l.cachedStringEnclosure == nil {
 		if !s.CanTokenize(l) {
 			return Token{Type: TypeInvalid, Position: l.GetPosition()},
-				NewError(fmt.Sprintf("Invalid character '%c' found", l.CharAtCursor()), l.GetPosition(), l.state.Content)
+				l.ErrorAt(ErrInvalidCharacter, fmt.Sprintf("Invalid character '%c' found", l.CharAtCursor()), l.GetPosition())
 		} else {
 			return s.Tokenize(l)
 		}
 	}
 
-	token, err := cachedStringEnclosure.Tokenize(l)
-	cachedStringEnclosure = nil
+	token, err := l.cachedStringEnclosure.Tokenize(l)
+	l.cachedStringEnclosure = nil
 
 	return token, err
 }
@@ -83,22 +81,27 @@ func (se StringEnclosure) TokenizeEscapable(l *Lexer) (Token, error) {
 	token.AppendChar(l.CharAtCursor())
 	l.IncrementCursor(1)
 
-	nextEnclosureCharIsEscaped := false
-	for !l.CursorIsOutOfBounds() && (l.CharAtCursor() != enclosureChar || nextEnclosureCharIsEscaped) {
-		if nextEnclosureCharIsEscaped {
-			nextEnclosureCharIsEscaped = false
-		}
-
-		if l.CharAtCursor() == '\\' && l.CharAtRelativePosition(1) == enclosureChar {
-			nextEnclosureCharIsEscaped = true
+	for !l.CursorIsOutOfBounds() && l.CharAtCursor() != enclosureChar {
+		if l.CharAtCursor() == '\\' {
+			if l.ValidateEscapeSequences && !escapeSequenceIsValid(l, enclosureChar) {
+				return token, l.ErrorAt(ErrInvalidEscape, fmt.Sprintf("Invalid escape sequence '\\%c'", l.CharAtRelativePosition(1)), l.GetPosition())
+			}
+
+			// The escaped character is consumed along with the backslash
+			token.AppendChar(l.CharAtCursor())
+			l.IncrementCursor(1)
+
+			if l.CursorIsOutOfBounds() {
+				break
+			}
 		}
 
 		token.AppendChar(l.CharAtCursor())
 		l.IncrementCursor(1)
+	}
 
-		if l.CharAtCursor() == EOF {
-			return token, NewError("Unterminated string literal", token.Position, l.state.Content)
-		}
+	if l.CursorIsOutOfBounds() {
+		return token, l.ErrorAt(ErrUnterminatedString, "Unterminated string literal", token.Position)
 	}
 
 	token.AppendChar(l.CharAtCursor())
@@ -121,7 +124,7 @@ func (se StringEnclosure) TokenizeNotEscapableSingleChar(l *Lexer) (Token, error
 		l.IncrementCursor(1)
 
 		if l.CharAtCursor() == EOF {
-			return token, NewError("Unterminated string literal", token.Position, l.state.Content)
+			return token, l.ErrorAt(ErrUnterminatedString, "Unterminated string literal", token.Position)
 		}
 	}
 
@@ -132,7 +135,7 @@ func (se StringEnclosure) TokenizeNotEscapableSingleChar(l *Lexer) (Token, error
 }
 
 func (se StringEnclosure) TokenizeNotEscapableMultiChar(l *Lexer) (Token, error) {
-	enclosureLen := len(se.Enclosure)
+	enclosureLen := len([]rune(se.Enclosure))
 	token := Token{Type: se.Type, Position: l.GetPosition()}
 	start := l.GetCursor()
 
@@ -144,7 +147,7 @@ func (se StringEnclosure) TokenizeNotEscapableMultiChar(l *Lexer) (Token, error)
 		l.IncrementCursor(1)
 
 		if l.CharAtCursor() == EOF {
-			return token, NewError("Unterminated string literal", token.Position, l.state.Content)
+			return token, l.ErrorAt(ErrUnterminatedString, "Unterminated string literal", token.Position)
 		}
 	}
 
@@ -155,3 +158,46 @@ func (se StringEnclosure) TokenizeNotEscapableMultiChar(l *Lexer) (Token, error)
 
 	return token, nil
 }
+
+// escapeSequenceIsValid checks if the backslash at the cursor starts a valid escape sequence.
+// Valid sequences are the ones supported by go plus the escaped enclosure character.
+func escapeSequenceIsValid(l *Lexer, enclosureChar rune) bool {
+	char := l.CharAtRelativePosition(1)
+	if char == enclosureChar {
+		return true
+	}
+
+	switch char {
+	case 'a', 'b', 'f', 'n', 'r', 't', 'v', '\\', '\'', '"':
+		return true
+	case 'x':
+		return nextCharsAreHex(l, 2, 2)
+	case 'u':
+		return nextCharsAreHex(l, 2, 4)
+	case 'U':
+		return nextCharsAreHex(l, 2, 8)
+	}
+
+	if char >= '0' && char <= '7' {
+		for i := 2; i <= 3; i++ {
+			if c := l.CharAtRelativePosition(i); c < '0' || c > '7' {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func nextCharsAreHex(l *Lexer, offset int, count int) bool {
+	for i := offset; i < offset+count; i++ {
+		c := l.CharAtRelativePosition(i)
+		if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'f') && !(c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+
+	return true
+}
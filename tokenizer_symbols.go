@@ -14,6 +14,10 @@ func (s SymbolTokenizer) CanTokenize(l *Lexer) bool {
 func (s SymbolTokenizer) Tokenize(l *Lexer) (Token, error) {
 	token := Token{Type: TypeSymbol, Position: l.GetPosition()}
 
+	// The start character was matched by CanTokenize and might not be part of the continue map
+	token.AppendChar(l.CharAtCursor())
+	l.IncrementCursor(1)
+
 	for !l.CursorIsOutOfBounds() {
 		if !strings.Contains(l.SymbolContinueCharacterMap, string(l.CharAtCursor())) {
 			break
@@ -13,12 +13,23 @@ type TokenCollection struct {
 	tokens       Tokens
 	tokensLength int
 	cursor       int
+	// lines is the line table of the source of the tokens, used for the snippets of errors
+	lines *LineTable
 }
 
 func NewTokenCollection(tokens Tokens) TokenCollection {
 	return TokenCollection{tokens: tokens, tokensLength: len(tokens), cursor: 0}
 }
 
+// NewTokenCollectionWithLines creates a collection of the tokens of the source of the line table,
+// like lexer.Lines(). Its errors include the source line of the token, like the errors of the lexer.
+func NewTokenCollectionWithLines(tokens Tokens, lines *LineTable) TokenCollection {
+	collection := NewTokenCollection(tokens)
+	collection.lines = lines
+
+	return collection
+}
+
 func (ti *TokenCollection) Iter() iter.Seq2[int, Token] {
 	return func(yield func(int, Token) bool) {
 		for ti.cursor = 0; ti.cursor < ti.tokensLength; ti.cursor++ {
@@ -29,9 +40,15 @@ func (ti *TokenCollection) Iter() iter.Seq2[int, Token] {
 	}
 }
 
+// Len returns the number of tokens in the collection
+func (t TokenCollection) Len() int { return t.tokensLength }
+
+// Cursor returns the position of the cursor
+func (t TokenCollection) Cursor() int { return t.cursor }
+
 // IncrementCursor increments the cursor by the amount
 func (t *TokenCollection) IncrementCursor(amount int) {
-	t.cursor += amount
+	t.cursor = min(max(t.cursor+amount, 0), t.tokensLength)
 }
 
 func (t TokenCollection) CursorIsOutOfBounds() bool {
@@ -45,7 +62,7 @@ func (t TokenCollection) ReachedEOF() bool {
 // NextToken increments the cursor position by 1
 // and returns the token at that position
 func (t *TokenCollection) NextToken() Token {
-	t.cursor += 1
+	t.IncrementCursor(1)
 	return t.TokenAtPosition(t.cursor)
 }
 
@@ -54,16 +71,108 @@ func (t *TokenCollection) TokenAtCursor() Token {
 	return t.TokenAtPosition(t.cursor)
 }
 
-// TokenAtPosition returns the token at the absolute position
+// TokenAtPosition returns the token at the absolute position.
+// Positions past the end return the EOF token and negative positions return the SOF token.
 func (t *TokenCollection) TokenAtPosition(pos int) Token {
-	// TODO: add out of bounds checking
+	if pos < 0 {
+		return Token{Type: TypeSof}
+	}
+
+	if pos >= t.tokensLength {
+		return t.eofToken()
+	}
+
 	return t.tokens[pos]
 }
 
 // TokenAtRelativePosition returns the token at the position relative to the cursor
 func (t *TokenCollection) TokenAtRelativePosition(pos int) Token {
-	// TODO: add out of bounds checking
-	return t.tokens[t.cursor+pos]
+	return t.TokenAtPosition(t.cursor + pos)
+}
+
+// eofToken returns the EOF token of the collection. Collections
+// which do not end with one get an EOF token at the end of the last token.
+func (t *TokenCollection) eofToken() Token {
+	if t.tokensLength == 0 {
+		return Token{Type: TypeEof, Literal: string(EOF)}
+	}
+
+	last := t.tokens[t.tokensLength-1]
+	if last.TypeIs(TypeEof) {
+		return last
+	}
+
+	return Token{Type: TypeEof, Literal: string(EOF), Position: last.End, End: last.End}
+}
+
+// ###################################################
+// #              Parser Helpers
+// ###################################################
+
+// Peek returns the token at the offset without moving the cursor. Like Lexer.Peek the next token,
+// which is the token at the cursor, is at offset 1 and the last consumed token at offset 0.
+// Peeking past the end returns the EOF token.
+func (t *TokenCollection) Peek(offset int) Token {
+	return t.TokenAtRelativePosition(offset - 1)
+}
+
+// Advance returns the token at the cursor and moves the cursor to the next token
+func (t *TokenCollection) Advance() Token {
+	token := t.TokenAtCursor()
+	t.IncrementCursor(1)
+
+	return token
+}
+
+// Backup moves the cursor back to the previous token
+func (t *TokenCollection) Backup() {
+	t.IncrementCursor(-1)
+}
+
+// Expect consumes the token at the cursor if it is of the token type.
+// Otherwise it returns an ErrUnexpectedToken error at the position of the token without moving the cursor.
+func (t *TokenCollection) Expect(tokenType TokenType) (Token, error) {
+	token := t.TokenAtCursor()
+	if !token.TypeIs(tokenType) {
+		return token, t.errorAt(ErrUnexpectedToken, fmt.Sprintf("expected %s but found %s", tokenType, token.Type), token)
+	}
+
+	t.IncrementCursor(1)
+	return token, nil
+}
+
+// Accept consumes the token at the cursor if it is of any of the token types
+func (t *TokenCollection) Accept(types ...TokenType) (Token, bool) {
+	token := t.TokenAtCursor()
+	if !token.TypeIsAnyOf(types...) {
+		return token, false
+	}
+
+	t.IncrementCursor(1)
+	return token, true
+}
+
+// errorAt creates an error of the kind spanning the token
+func (t *TokenCollection) errorAt(kind *ErrorKind, message string, token Token) *Error {
+	var e *Error
+	if t.lines != nil {
+		e = NewErrorInLines(kind, message, token.Position, t.lines)
+	} else {
+		e = NewErrorOfKind(kind, message, token.Position, nil)
+	}
+
+	e.End = token.End
+	return e
+}
+
+// Mark returns the position of the cursor which can be returned to using Reset
+func (t *TokenCollection) Mark() int {
+	return t.cursor
+}
+
+// Reset moves the cursor back to the mark
+func (t *TokenCollection) Reset(mark int) {
+	t.cursor = min(max(mark, 0), t.tokensLength)
 }
 
 // CollectTokensBetweenParentheses collects all the tokens between
@@ -106,11 +215,10 @@ func (t *TokenCollection) CollectTokensBetween(open TokenType, close TokenType)
 
 	for !token.TypeIs(TypeEof) {
 		end = t.cursor
-		t.cursor += 1
-		token = t.tokens[t.cursor]
+		token = t.NextToken()
 
 		if token.TypeIs(TypeEof) {
-			return collected, start, end, fmt.Errorf("Unexpected EndOfFile")
+			return collected, start, end, t.errorAt(ErrUnexpectedEOF, "Unexpected EndOfFile", token)
 		}
 
 		if token.TypeIs(close) {
@@ -136,7 +244,7 @@ func (t *TokenCollection) CollectTokensDelimited(tokenType TokenType, delimiter
 	token := t.TokenAtCursor()
 	for !token.TypeIs(TypeEof) {
 		if !token.TypeIs(tokenType) {
-			return tokens, fmt.Errorf("expected %s but found %s", tokenType, token.Type)
+			return tokens, t.errorAt(ErrUnexpectedToken, fmt.Sprintf("expected %s but found %s", tokenType, token.Type), token)
 		}
 
 		tokens = append(tokens, token)
@@ -170,17 +278,14 @@ func (t *TokenCollection) CollectAnyTokensDelimited(delimiter TokenType) ([]Toke
 	return tokens, nil
 }
 
+// CollectTokensUntil collects all the tokens from the cursor until the
+// delimiter or the end, leaving the cursor on the delimiter
 func (t *TokenCollection) CollectTokensUntil(delimiter TokenType) ([]Token, error) {
 	tokens := []Token{}
 
 	token := t.TokenAtCursor()
 	for !token.TypeIs(TypeEof) && !token.TypeIs(delimiter) {
 		tokens = append(tokens, token)
-
-		if !t.TokenAtRelativePosition(1).TypeIs(delimiter) {
-			break
-		}
-
 		token = t.NextToken()
 	}
 
@@ -1,15 +1,215 @@
 package golex
 
-import "testing"
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func getTokenCollection(t *testing.T, source string) TokenCollection {
+	t.Helper()
+
+	tokens, err := NewLexer().TokenizeToSlice(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewTokenCollection(tokens)
+}
+
+func literals(tokens []Token) []string {
+	result := []string{}
+	for _, token := range tokens {
+		result = append(result, token.Literal)
+	}
+
+	return result
+}
 
 func TestTokenCollectionUsage(t *testing.T) {
+	fmt.Println("TestTokenCollectionUsage...")
+
+	tokens := getTokenCollection(t, "let x = 1;")
+
+	if tokens.Peek(1).Literal != "let" || tokens.Peek(4).Literal != "1" {
+		t.Errorf("Expected to peek let and 1 but got %s and %s", tokens.Peek(1).Literal, tokens.Peek(4).Literal)
+	}
+
+	// Out of bounds positions do not panic
+	if !tokens.Peek(100).TypeIs(TypeEof) || !tokens.TokenAtPosition(100).TypeIs(TypeEof) || !tokens.Peek(0).TypeIs(TypeSof) {
+		t.Errorf("Expected EOF past the end and SOF before the start")
+	}
+
+	if token := tokens.Advance(); token.Literal != "let" || tokens.Cursor() != 1 {
+		t.Errorf("Expected to advance past let but got %s at %d", token.Literal, tokens.Cursor())
+	}
+
+	tokens.Backup()
+	if token, ok := tokens.Accept(TypeSymbol, TypeKeyword); !ok || token.Literal != "let" {
+		t.Errorf("Expected to accept let but got %s", token.Literal)
+	}
+
+	if _, ok := tokens.Accept(TypeAssign); ok || tokens.Cursor() != 1 {
+		t.Errorf("Expected not to accept x as an assignment")
+	}
+
+	mark := tokens.Mark()
+	tokens.Advance()
+	if _, err := tokens.Expect(TypeAssign); err != nil {
+		t.Error(err)
+	}
+
+	_, err := tokens.Expect(TypeString)
+
+	var lexErr *Error
+	if !errors.Is(err, ErrUnexpectedToken) || !errors.As(err, &lexErr) || lexErr.Position.Col != 9 {
+		t.Errorf("Expected an unexpected token error at column 9 but got %v", err)
+	}
+
+	tokens.Reset(mark)
+	if tokens.Peek(1).Literal != "x" || tokens.Peek(0).Literal != "let" {
+		t.Errorf("Expected to reset to x after let but got %s after %s", tokens.Peek(1).Literal, tokens.Peek(0).Literal)
+	}
+
+	for !tokens.ReachedEOF() {
+		tokens.Advance()
+	}
+
+	if !tokens.Advance().TypeIs(TypeEof) || tokens.Cursor() != tokens.Len() {
+		t.Errorf("Expected to stay at the end after advancing past EOF")
+	}
+
+	// Collections without an EOF token get one at the end of the last token
+	tokens = NewTokenCollection(tokens.tokens[:2])
+	if eof := tokens.Peek(3); !eof.TypeIs(TypeEof) || eof.Position.Col != 6 {
+		t.Errorf("Expected an EOF token at column 6 but got %s at %s", eof.Type, eof.Position)
+	}
+}
 
+func TestTokenCollectionErrors(t *testing.T) {
+	fmt.Println("TestTokenCollectionErrors...")
+
+	lexer := NewLexer()
+	tokens, err := lexer.TokenizeToSlice("let x\n  = 1;")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collection := NewTokenCollectionWithLines(tokens, lexer.Lines())
+	collection.Advance()
+	_, err = collection.Expect(TypeAssign)
+
+	expect := "  1:   5: expected Assign but found Symbol\n" +
+		"1 | let x\n" +
+		"  |     ^"
+
+	if err == nil || err.Error() != expect {
+		t.Errorf("Expected:\n%s\nGot:\n%v", expect, err)
+	}
+
+	// The lexer and the collection agree on the offset of the next token
+	lexer.TokenizeManual("let x")
+	lexer.NextToken()
+	if lexer.Peek(0).Literal != "let" || lexer.Peek(1).Literal != "x" || collection.Peek(0).Literal != "let" || collection.Peek(1).Literal != "x" {
+		t.Errorf("Expected the last consumed token at offset 0 and the next token at offset 1")
+	}
 }
 
 func TestTokenCollectionCollectBetween(t *testing.T) {
+	fmt.Println("TestTokenCollectionCollectBetween...")
+
+	tokens := getTokenCollection(t, "f(a, (b), c) d")
+	tokens.IncrementCursor(1)
 
+	collected, start, end, err := tokens.CollectTokensBetweenParentheses()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	differ := &Differ{}
+	differ.Compare([]string{"a", ",", "(", "b", ")", ",", "c"}, literals(collected))
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+
+	if start != 1 || end != 8 || tokens.TokenAtCursor().Literal != ")" {
+		t.Errorf("Expected the collected portion to be 1-8 ending on ) but got %d-%d on %s", start, end, tokens.TokenAtCursor().Literal)
+	}
+
+	tokens = getTokenCollection(t, "{ a { b }")
+	if _, _, _, err := tokens.CollectTokensBetweenCurlyBraces(); !errors.Is(err, ErrUnexpectedEOF) {
+		t.Errorf("Expected an unexpected EOF error but got %v", err)
+	}
+
+	tokens = getTokenCollection(t, "a")
+	if _, _, _, err := tokens.CollectTokensBetweenCurlyBraces(); err == nil {
+		t.Errorf("Expected an error when not starting on the opener")
+	}
+}
+
+func TestTokenCollectionCollectDelimited(t *testing.T) {
+	fmt.Println("TestTokenCollectionCollectDelimited...")
+
+	tokens := getTokenCollection(t, "a, b, c; d")
+	collected, err := tokens.CollectTokensDelimited(TypeSymbol, TypeComma)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	differ := &Differ{}
+	differ.Compare([]string{"a", "b", "c"}, literals(collected))
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+
+	tokens = getTokenCollection(t, "a, 1")
+	if _, err := tokens.CollectTokensDelimited(TypeSymbol, TypeComma); !errors.Is(err, ErrUnexpectedToken) {
+		t.Errorf("Expected an unexpected token error but got %v", err)
+	}
+
+	tokens = getTokenCollection(t, "a, 1, \"b\"")
+	collected, err = tokens.CollectAnyTokensDelimited(TypeComma)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	differ.Compare([]string{"a", "1", "\"b\""}, literals(collected))
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
 }
 
 func TestTokenCollectionCollectUntil(t *testing.T) {
+	fmt.Println("TestTokenCollectionCollectUntil...")
+
+	tokens := getTokenCollection(t, "a = b + c; d")
+	collected, err := tokens.CollectTokensUntil(TypeSemicolon)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	differ := &Differ{}
+	differ.Compare([]string{"a", "=", "b", "+", "c"}, literals(collected))
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+
+	if !tokens.TokenAtCursor().TypeIs(TypeSemicolon) {
+		t.Errorf("Expected the cursor to be on the delimiter but got %s", tokens.TokenAtCursor().Type)
+	}
+
+	collected, _ = tokens.CollectTokensUntil(TypeSemicolon)
+	if len(collected) != 0 {
+		t.Errorf("Expected nothing to be collected when starting on the delimiter but got %d tokens", len(collected))
+	}
 
+	tokens.Advance()
+	collected, _ = tokens.CollectTokensUntil(TypeSemicolon)
+	if len(collected) != 1 || !tokens.ReachedEOF() {
+		t.Errorf("Expected to collect until the end but got %d tokens", len(collected))
+	}
 }
@@ -0,0 +1,88 @@
+package golex
+
+import (
+	"sort"
+	"unicode"
+)
+
+// DefaultTabWidth is the tab width used when none is configured
+const DefaultTabWidth = 4
+
+// wideRanges are the East Asian Wide (W) and Fullwidth (F) ranges of Unicode 15.1 which take up two columns.
+// The emoji blocks mix wide emoji with narrow symbols that default to text presentation, like U+1F321 THERMOMETER,
+// so only their W ranges are listed.
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F}, {0x231A, 0x231B}, {0x2329, 0x232A}, {0x23E9, 0x23EC}, {0x23F0, 0x23F0},
+	{0x23F3, 0x23F3}, {0x25FD, 0x25FE}, {0x2614, 0x2615}, {0x2648, 0x2653}, {0x267F, 0x267F},
+	{0x2693, 0x2693}, {0x26A1, 0x26A1}, {0x26AA, 0x26AB}, {0x26BD, 0x26BE}, {0x26C4, 0x26C5},
+	{0x26CE, 0x26CE}, {0x26D4, 0x26D4}, {0x26EA, 0x26EA}, {0x26F2, 0x26F3}, {0x26F5, 0x26F5},
+	{0x26FA, 0x26FA}, {0x26FD, 0x26FD}, {0x2705, 0x2705}, {0x270A, 0x270B}, {0x2728, 0x2728},
+	{0x274C, 0x274C}, {0x274E, 0x274E}, {0x2753, 0x2755}, {0x2757, 0x2757}, {0x2795, 0x2797},
+	{0x27B0, 0x27B0}, {0x27BF, 0x27BF}, {0x2B1B, 0x2B1C}, {0x2B50, 0x2B50}, {0x2B55, 0x2B55},
+	{0x2E80, 0x303E}, {0x3041, 0x33FF}, {0x3400, 0x4DBF}, {0x4E00, 0x9FFF}, {0xA000, 0xA4CF},
+	{0xA960, 0xA97F}, {0xAC00, 0xD7A3}, {0xF900, 0xFAFF}, {0xFE10, 0xFE19}, {0xFE30, 0xFE6F},
+	{0xFF00, 0xFF60}, {0xFFE0, 0xFFE6}, {0x16FE0, 0x16FE4}, {0x17000, 0x18AFF}, {0x1B000, 0x1B2FF},
+	{0x1F004, 0x1F004}, {0x1F0CF, 0x1F0CF}, {0x1F18E, 0x1F18E}, {0x1F191, 0x1F19A}, {0x1F200, 0x1F202},
+	{0x1F210, 0x1F23B}, {0x1F240, 0x1F248}, {0x1F250, 0x1F251}, {0x1F260, 0x1F265}, {0x1F300, 0x1F320},
+	{0x1F32D, 0x1F335}, {0x1F337, 0x1F37C}, {0x1F37E, 0x1F393}, {0x1F3A0, 0x1F3CA}, {0x1F3CF, 0x1F3D3},
+	{0x1F3E0, 0x1F3F0}, {0x1F3F4, 0x1F3F4}, {0x1F3F8, 0x1F43E}, {0x1F440, 0x1F440}, {0x1F442, 0x1F4FC},
+	{0x1F4FF, 0x1F53D}, {0x1F54B, 0x1F54E}, {0x1F550, 0x1F567}, {0x1F57A, 0x1F57A}, {0x1F595, 0x1F596},
+	{0x1F5A4, 0x1F5A4}, {0x1F5FB, 0x1F64F}, {0x1F680, 0x1F6C5}, {0x1F6CC, 0x1F6CC}, {0x1F6D0, 0x1F6D2},
+	{0x1F6D5, 0x1F6D7}, {0x1F6DC, 0x1F6DF}, {0x1F6EB, 0x1F6EC}, {0x1F6F4, 0x1F6FC}, {0x1F7E0, 0x1F7EB},
+	{0x1F7F0, 0x1F7F0}, {0x1F90C, 0x1F93A}, {0x1F93C, 0x1F945}, {0x1F947, 0x1F9FF}, {0x1FA70, 0x1FA7C},
+	{0x1FA80, 0x1FA88}, {0x1FA90, 0x1FABD}, {0x1FABF, 0x1FAC5}, {0x1FACE, 0x1FADB}, {0x1FAE0, 0x1FAE8},
+	{0x1FAF0, 0x1FAF8}, {0x20000, 0x2FFFD}, {0x30000, 0x3FFFD},
+}
+
+// RuneWidth returns the number of columns the rune takes up when displayed.
+// Wide and fullwidth East Asian characters take up two columns, combining marks,
+// format and control characters none. Tabs depend on their column, see NextVisualColumn.
+func RuneWidth(r rune) int {
+	if r == 0 || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) || unicode.Is(unicode.Cf, r) || unicode.IsControl(r) {
+		return 0
+	}
+
+	i := sort.Search(len(wideRanges), func(i int) bool {
+		return wideRanges[i][1] >= r
+	})
+
+	if i < len(wideRanges) && wideRanges[i][0] <= r {
+		return 2
+	}
+
+	return 1
+}
+
+// NextVisualColumn returns the 1-based visual column after displaying the rune at the column
+func NextVisualColumn(col int, r rune, tabWidth int) int {
+	if r == '\t' {
+		if tabWidth <= 0 {
+			tabWidth = DefaultTabWidth
+		}
+
+		return ((col-1)/tabWidth+1)*tabWidth + 1
+	}
+
+	return col + RuneWidth(r)
+}
+
+// StringWidth returns the number of columns the text takes up when displayed from the start of a line
+func StringWidth(text string, tabWidth int) int {
+	return runesWidth([]rune(text), 1, tabWidth)
+}
+
+// VisualColumn returns the 1-based visual column of the 1-based rune column in the line
+func VisualColumn(line string, col int, tabWidth int) int {
+	runes := []rune(line)
+	return runesWidth(runes[:min(max(col-1, 0), len(runes))], 1, tabWidth) + 1
+}
+
+// runesWidth returns the display width of the runes when displayed from the visual column
+func runesWidth(runes []rune, col int, tabWidth int) int {
+	start := col
+	for _, r := range runes {
+		col = NextVisualColumn(col, r, tabWidth)
+	}
+
+	return col - start
+}
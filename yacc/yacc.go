@@ -0,0 +1,149 @@
+// Package yacc adapts golex lexers to parsers generated by goyacc.
+//
+// A goyacc parser reads its tokens from a yyLexer, an interface with the methods
+// Lex(lval *yySymType) int and Error(string). Both types are generated per
+// grammar, so the Adapter is generic over the semantic value type S and
+// implements yyLexer when instantiated with the generated yySymType:
+//
+//	tokens := yacc.NewTokenMap().
+//		Type(golex.TypeInteger, NUMBER).
+//		Type(golex.TypeSymbol, IDENT).
+//		Literal("let", LET)
+//
+//	adapter := yacc.NewAdapter(lexer, tokens, func(lval *yySymType, token golex.Token) {
+//		lval.value = token.Value
+//	})
+//
+//	yyParse(adapter)
+//	err := adapter.Err()
+package yacc
+
+import (
+	"errors"
+	"math"
+	"unicode/utf8"
+
+	"github.com/cornejong/golex"
+)
+
+// Unknown is returned for tokens without a yacc token ID, goyacc reports them as $unk
+const Unknown = math.MaxInt32
+
+// TokenMap maps golex token types and literals to the token IDs of a yacc grammar.
+// Literals take precedence over token types, so a keyword like "let" can have its
+// own ID even though it is lexed as a Symbol.
+type TokenMap struct {
+	types    map[golex.TokenType]int
+	literals map[string]int
+}
+
+// NewTokenMap creates an empty token map
+func NewTokenMap() *TokenMap {
+	return &TokenMap{types: map[golex.TokenType]int{}, literals: map[string]int{}}
+}
+
+// Type maps the tokens of the token type to the ID
+func (m *TokenMap) Type(tokenType golex.TokenType, id int) *TokenMap {
+	m.types[tokenType] = id
+	return m
+}
+
+// Literal maps the tokens with the literal to the ID
+func (m *TokenMap) Literal(literal string, id int) *TokenMap {
+	m.literals[literal] = id
+	return m
+}
+
+// ID returns the yacc token ID of the token. The end of the input is 0, which goyacc
+// treats as the end. Unmapped literals of a single character are returned as that
+// character, matching character tokens like '+' in the grammar, other unmapped
+// tokens are Unknown.
+func (m *TokenMap) ID(token golex.Token) int {
+	if token.TypeIs(golex.TypeEof) {
+		return 0
+	}
+
+	if id, ok := m.literals[token.Literal]; ok && token.Literal != "" {
+		return id
+	}
+
+	if id, ok := m.types[token.Type]; ok {
+		return id
+	}
+
+	if r, size := utf8.DecodeRuneInString(token.Literal); size > 0 && size == len(token.Literal) {
+		return int(r)
+	}
+
+	return Unknown
+}
+
+// ###################################################
+// #              Adapter
+// ###################################################
+
+// Adapter wraps a lexer as the yyLexer of a goyacc parser with the semantic value type S
+type Adapter[S any] struct {
+	Lexer  *golex.Lexer
+	Tokens *TokenMap
+	// SetValue stores the token in the semantic value, typically its Value, it is optional
+	SetValue func(lval *S, token golex.Token)
+	// Token is the token returned by the last call of Lex
+	Token golex.Token
+	// Errors holds the errors of the lexer and the parser in the order they occurred
+	Errors []*golex.Error
+}
+
+// NewAdapter creates an adapter reading the tokens of the lexer
+func NewAdapter[S any](lexer *golex.Lexer, tokens *TokenMap, setValue func(lval *S, token golex.Token)) *Adapter[S] {
+	return &Adapter[S]{Lexer: lexer, Tokens: tokens, SetValue: setValue}
+}
+
+// Lex returns the ID of the next token and stores it in the semantic value.
+// A lexer error is recorded and ends the input.
+func (a *Adapter[S]) Lex(lval *S) int {
+	token, err := a.Lexer.NextToken()
+	if err != nil {
+		a.record(err, token)
+		a.Token = golex.Token{Type: golex.TypeEof, Position: token.Position, End: token.Position}
+
+		return 0
+	}
+
+	a.Token = token
+	if a.SetValue != nil && lval != nil {
+		a.SetValue(lval, token)
+	}
+
+	return a.Tokens.ID(token)
+}
+
+// Error records the error of the parser, like "syntax error: unexpected IDENT",
+// as a golex error at the position of the last token
+func (a *Adapter[S]) Error(message string) {
+	kind := golex.ErrUnexpectedToken
+	if a.Token.TypeIs(golex.TypeEof) {
+		kind = golex.ErrUnexpectedEOF
+	}
+
+	a.Errors = append(a.Errors, a.Lexer.ErrorAt(kind, message, a.Token.Position))
+}
+
+// Err returns the recorded errors joined in a single error, or nil when there are none
+func (a *Adapter[S]) Err() error {
+	errs := make([]error, len(a.Errors))
+	for i, err := range a.Errors {
+		errs[i] = err
+	}
+
+	return errors.Join(errs...)
+}
+
+func (a *Adapter[S]) record(err error, token golex.Token) {
+	var lexErr *golex.Error
+	if !errors.As(err, &lexErr) {
+		lexErr = a.Lexer.ErrorAt(nil, err.Error(), token.Position)
+	}
+
+	a.Errors = append(a.Errors, lexErr)
+}
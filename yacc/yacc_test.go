@@ -0,0 +1,106 @@
+package yacc
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cornejong/golex"
+)
+
+// The types goyacc generates for a grammar
+type yySymType struct {
+	yys   int
+	value any
+}
+
+type yyLexer interface {
+	Lex(lval *yySymType) int
+	Error(s string)
+}
+
+const (
+	NUMBER = 57346 + iota
+	IDENT
+	LET
+)
+
+func getAdapter(source string) *Adapter[yySymType] {
+	lexer := golex.NewLexer()
+	lexer.TokenizeManual(source)
+
+	tokens := NewTokenMap().
+		Type(golex.TypeInteger, NUMBER).
+		Type(golex.TypeSymbol, IDENT).
+		Literal("let", LET)
+
+	return NewAdapter(lexer, tokens, func(lval *yySymType, token golex.Token) {
+		lval.value = token.Value
+	})
+}
+
+func TestAdapterLex(t *testing.T) {
+	fmt.Println("TestAdapterLex...")
+
+	var lexer yyLexer = getAdapter("let x = 12 + y;")
+
+	ids := []int{}
+	values := []any{}
+	for {
+		var lval yySymType
+		id := lexer.Lex(&lval)
+		if id <= 0 {
+			break
+		}
+
+		ids = append(ids, id)
+		values = append(values, lval.value)
+	}
+
+	differ := &golex.Differ{}
+	differ.Compare([]int{LET, IDENT, '=', NUMBER, '+', IDENT, ';'}, ids)
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+
+	if values[3] != 12 {
+		t.Errorf("Expected the value of the number to be stored but got %#v", values[3])
+	}
+
+	if id := NewTokenMap().ID(golex.Token{Type: golex.TypeSymbol, Literal: "abc"}); id != Unknown {
+		t.Errorf("Expected an unmapped token to be unknown but got %d", id)
+	}
+}
+
+func TestAdapterErrors(t *testing.T) {
+	fmt.Println("TestAdapterErrors...")
+
+	adapter := getAdapter("let x")
+	adapter.Lex(nil)
+	adapter.Lex(nil)
+	adapter.Error("syntax error: unexpected IDENT")
+
+	if adapter.Lex(nil) != 0 {
+		t.Fatalf("Expected the end of the input")
+	}
+
+	adapter.Error("syntax error: unexpected $end")
+
+	differ := &golex.Differ{}
+	differ.Compare("1:5 syntax error: unexpected IDENT", adapter.Errors[0].Position.Location()+" "+adapter.Errors[0].Message)
+	if differ.HasDifference() {
+		fmt.Println(differ)
+		t.FailNow()
+	}
+
+	if !errors.Is(adapter.Errors[0], golex.ErrUnexpectedToken) || !errors.Is(adapter.Errors[1], golex.ErrUnexpectedEOF) {
+		t.Errorf("Expected an unexpected token and an unexpected EOF error but got %v", adapter.Err())
+	}
+
+	adapter = getAdapter(`let "x`)
+	adapter.Lex(nil)
+	if adapter.Lex(nil) != 0 || !errors.Is(adapter.Err(), golex.ErrUnterminatedString) {
+		t.Errorf("Expected the lexer error to end the input but got %v", adapter.Err())
+	}
+}